@@ -0,0 +1,106 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Command checktranslations reports the texts missing in each language
+// compared to a reference language. It exits with status 1 if any text is
+// missing.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/quixsi/core/internal/db"
+	"github.com/quixsi/core/internal/db/jsondb"
+	"github.com/quixsi/core/internal/db/kvdb"
+	"github.com/quixsi/core/internal/model"
+)
+
+func main() {
+	var (
+		dbStr     = flag.String("db", "json://testdata", "database connection string")
+		reference = flag.String("reference", model.FallbackLanguage, "reference language")
+	)
+	flag.Parse()
+
+	tStore, closeFn, err := openTranslationStore(*dbStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	missing, err := check(context.Background(), tStore, *reference)
+	closeFn()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if missing > 0 {
+		os.Exit(1)
+	}
+}
+
+// check prints the missing texts of every language and returns their count.
+func check(ctx context.Context, tStore db.TranslationStore, reference string) (int, error) {
+	ref, err := tStore.ByLanguage(ctx, reference)
+	if err != nil {
+		return 0, fmt.Errorf("reference language %q: %w", reference, err)
+	}
+	langs, err := tStore.ListLanguages(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, lang := range langs {
+		if lang == reference {
+			continue
+		}
+		t, err := tStore.ByLanguage(ctx, lang)
+		if err != nil {
+			return 0, fmt.Errorf("language %q: %w", lang, err)
+		}
+		missing := t.Missing(ref)
+		for _, key := range missing {
+			fmt.Printf("%s: missing %s\n", lang, key)
+		}
+		count += len(missing)
+	}
+	return count, nil
+}
+
+// openTranslationStore opens the translations of a database connection
+// string as accepted by the server.
+func openTranslationStore(dbStr string) (db.TranslationStore, func() error, error) {
+	u, err := url.Parse(dbStr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse db connection string: %w", err)
+	}
+
+	switch u.Scheme {
+	case "json":
+		tStore, err := jsondb.NewTranslationStore(u.Host + u.Path + "/translations.json")
+		if err != nil {
+			return nil, nil, err
+		}
+		return tStore, func() error { return nil }, nil
+	case "kvdb":
+		bdb, err := bolt.Open(u.Host+u.Path, 0600, &bolt.Options{Timeout: time.Second})
+		if err != nil {
+			return nil, nil, err
+		}
+		tStore, err := kvdb.NewTranslationStore(bdb)
+		if err != nil {
+			bdb.Close()
+			return nil, nil, err
+		}
+		return tStore, bdb.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q", u.Scheme)
+	}
+}
@@ -73,7 +73,13 @@ func into(dst, src database) {
 		panic(err)
 	}
 	for _, inv := range invites {
-		if _, err := dst.CreateInvitation(ctx, inv.GuestIDs...); err != nil {
+		created, err := dst.CreateInvitation(ctx, inv.GuestIDs...)
+		if err != nil {
+			panic(err)
+		}
+		created.MaxGuests = inv.MaxGuests
+		created.MaxPlusOnes = inv.MaxPlusOnes
+		if err := dst.UpdateInvitation(ctx, created); err != nil {
 			panic(err)
 		}
 	}
@@ -10,6 +10,8 @@ import (
 	"log/slog"
 	"os"
 	"time"
+	// NOTE: the container image has no time zone database.
+	_ "time/tzdata"
 
 	"net/http"
 	"net/url"
@@ -21,21 +23,26 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/quixsi/core/internal/blob"
 	"github.com/quixsi/core/internal/db"
 	"github.com/quixsi/core/internal/db/jsondb"
 	"github.com/quixsi/core/internal/db/kvdb"
+	"github.com/quixsi/core/internal/geocode"
 	"github.com/quixsi/core/internal/server"
 )
 
 func main() {
 	var (
-		serviceName = flag.String("service-name", "party-invite", "otel service name")
-		addr        = flag.String("addr", "0.0.0.0:8080", "default server address")
-		dbStr       = flag.String("db", "json://testdata", "database connection string")
-		otlpAddr    = flag.String("otlp-grpc", "", "default otlp/gRPC address, by default disabled. Example value: localhost:4317")
-		logLevelArg = flag.String("log-level", "INFO", "log level")
-		staticDir   = flag.String("static-dir", "", "path to static directory")
-		deadline    = flag.String("deadline", "", "deadline in format: 01 May 24 10:00 CET")
+		serviceName  = flag.String("service-name", "party-invite", "otel service name")
+		addr         = flag.String("addr", "0.0.0.0:8080", "default server address")
+		dbStr        = flag.String("db", "json://testdata", "database connection string")
+		mediaStr     = flag.String("media", "file://testdata/media", "blob store of uploaded images: file://<dir> or s3://<bucket>/<prefix>?endpoint=<url>&region=<region>, with the credentials in AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		geocoderStr  = flag.String("geocoder", "", "provider filling the coordinates of locations from their address, by default disabled: nominatim, nominatim+<url> of a self-hosted instance or fixture://<file>")
+		geocodeCache = flag.String("geocode-cache", "testdata/geocode-cache.json", "file caching the results of the geocoder")
+		otlpAddr     = flag.String("otlp-grpc", "", "default otlp/gRPC address, by default disabled. Example value: localhost:4317")
+		logLevelArg  = flag.String("log-level", "INFO", "log level")
+		staticDir    = flag.String("static-dir", "", "path to static directory")
+		deadline     = flag.String("deadline", "", "fallback response deadline if the event has none, in ISO 8601 format: 2024-05-01T10:00:00+02:00 (RFC822 is still accepted)")
 	)
 	flag.Parse()
 	fmt.Println("logLevel", *logLevelArg)
@@ -60,7 +67,7 @@ func main() {
 		grpcOptions := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock()}
 		conn, err := grpc.DialContext(ctx, *otlpAddr, grpcOptions...)
 		if err != nil {
-			logger.Error("failed to create gRPC connection to collector", err)
+			logger.Error("failed to create gRPC connection to collector", "error", err)
 			os.Exit(1)
 		}
 		defer conn.Close()
@@ -68,7 +75,7 @@ func main() {
 		// Set up a trace exporter
 		otelExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
 		if err != nil {
-			logger.Error("failed to create trace exporter", err)
+			logger.Error("failed to create trace exporter", "error", err)
 			os.Exit(1)
 		}
 		tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(otelExporter))
@@ -78,10 +85,13 @@ func main() {
 	var dline time.Time
 	if *deadline != "" {
 		var err error
-		dline, err = time.Parse(time.RFC822, *deadline)
+		dline, err = time.Parse(time.RFC3339, *deadline)
+		if err != nil {
+			dline, err = time.Parse(time.RFC822, *deadline)
+		}
 		logger.Info("deadline set to", "date", *deadline)
 		if err != nil {
-			logger.Error("failed to parse deadline", err)
+			logger.Error("failed to parse deadline", "error", err)
 			os.Exit(1)
 		}
 	}
@@ -91,6 +101,7 @@ func main() {
 		invitationStore  db.InvitationStore
 		eventStore       db.EventStore
 		translationStore db.TranslationStore
+		tableStore       db.TableStore
 	)
 
 	u, err := url.Parse(*dbStr)
@@ -123,6 +134,11 @@ func main() {
 			logger.Error("could not initialize event store", "error", err)
 			os.Exit(1)
 		}
+		tableStore, err = jsondb.NewTableStore(base + "/tables.json")
+		if err != nil {
+			logger.Error("could not initialize table store", "error", err)
+			os.Exit(1)
+		}
 	case "kvdb":
 		path := u.Host + u.Path
 		db, err := bolt.Open(path, 0600, nil)
@@ -154,22 +170,56 @@ func main() {
 		if err != nil {
 			logger.Error("initialize translation bucket", "error", err)
 		}
+
+		tableStore, err = kvdb.NewTableStore(db)
+		if err != nil {
+			logger.Error("could not initialize table bucket", "error", err)
+			os.Exit(1)
+		}
 	default:
 		logger.Error("Unknown storage backend", "type", u.Scheme)
 		os.Exit(1)
 	}
 
+	blobStore, err := blob.Open(*mediaStr)
+	if err != nil {
+		logger.Error("could not initialize media store", "error", err)
+		os.Exit(1)
+	}
+
+	geocoder, err := geocode.Open(*geocoderStr)
+	if err != nil {
+		logger.Error("could not initialize geocoder", "error", err)
+		os.Exit(1)
+	}
+	if geocoder != nil {
+		geocoder, err = geocode.NewCache(geocoder, *geocodeCache)
+		if err != nil {
+			logger.Error("could not read geocoding cache", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	handler, err := server.NewServer(
+		*serviceName,
+		*staticDir,
+		dline,
+		invitationStore,
+		guestsStore,
+		translationStore,
+		eventStore,
+		tableStore,
+		blobStore,
+		geocoder,
+	)
+	if err != nil {
+		logger.Error("static files are missing, run \"make assets\" before building", "error", err)
+		os.Exit(1)
+	}
+
 	srv := &http.Server{
-		Addr: *addr,
-		Handler: server.NewServer(
-			*serviceName,
-			*staticDir,
-			dline,
-			invitationStore,
-			guestsStore,
-			translationStore,
-			eventStore,
-		),
+		Addr:    *addr,
+		Handler: handler,
 	}
 
 	if err := srv.ListenAndServe(); err != nil {
@@ -0,0 +1,84 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package blob stores binary objects like uploaded images by key, either in
+// a directory or in an S3-compatible bucket.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned for keys without a blob.
+	ErrNotFound = errors.New("blob not found")
+	// ErrInvalidKey is returned for keys that are no relative slash separated
+	// path of lower case letters, digits, dots, dashes and underscores.
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9_-][a-z0-9._-]*(/[a-z0-9_-][a-z0-9._-]*)*$`)
+
+// CheckKey returns ErrInvalidKey if key can not be used as blob key. Keys may
+// not contain "..", so they can not point outside of a directory.
+func CheckKey(key string) error {
+	if !keyPattern.MatchString(key) || strings.Contains(key, "..") {
+		return fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// Blob is a stored object.
+type Blob struct {
+	ContentType string
+	Data        []byte
+}
+
+type Store interface {
+	// Put stores data under key and replaces an existing blob.
+	Put(ctx context.Context, key string, blob *Blob) error
+	// Get returns the blob stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) (*Blob, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is no
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Open returns the store described by a connection string:
+//
+//	file:///var/lib/party/media
+//	s3://bucket/prefix?endpoint=http://localhost:9000&region=us-east-1
+//
+// The S3 credentials are read from AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY.
+func Open(connection string) (Store, error) {
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "file":
+		return NewFileStore(u.Host + u.Path)
+	case "s3":
+		endpoint := u.Query().Get("endpoint")
+		if endpoint == "" {
+			endpoint = "https://s3.amazonaws.com"
+		}
+		return NewS3Store(S3Config{
+			Endpoint:        endpoint,
+			Bucket:          u.Host,
+			Prefix:          strings.Trim(u.Path, "/"),
+			Region:          u.Query().Get("region"),
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", u.Scheme)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package catalog reads and writes the texts of translations in the file
+// formats of translation tools: flat JSON, gettext PO and XLIFF 1.2.
+package catalog
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownFormat is returned for files in none of the supported formats.
+var ErrUnknownFormat = errors.New("unknown catalog format")
+
+// Format is a file format of catalogs.
+type Format string
+
+const (
+	FormatJSON  Format = "json"
+	FormatPO    Format = "po"
+	FormatXLIFF Format = "xliff"
+)
+
+// Formats are the supported formats.
+var Formats = []Format{FormatJSON, FormatPO, FormatXLIFF}
+
+// Extension returns the file extension of the format including the dot.
+func (f Format) Extension() string {
+	if f == FormatXLIFF {
+		return ".xlf"
+	}
+	return "." + string(f)
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPO:
+		return "text/x-gettext-translation; charset=utf-8"
+	case FormatXLIFF:
+		return "application/xliff+xml; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// DetectFormat returns the format of a file by its name or, if the name does
+// not tell, its content.
+func DetectFormat(filename string, data []byte) (Format, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".po", ".pot":
+		return FormatPO, nil
+	case ".xlf", ".xliff":
+		return FormatXLIFF, nil
+	}
+	content := strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff"))
+	switch {
+	case strings.HasPrefix(content, "{"):
+		return FormatJSON, nil
+	case strings.HasPrefix(content, "<"):
+		return FormatXLIFF, nil
+	case strings.Contains(content, "msgid "):
+		return FormatPO, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Catalog contains the texts of one language by their key, e.g.
+// "guest_form.label_yes".
+type Catalog struct {
+	Language string
+	Texts    map[string]string
+
+	// SourceLanguage and Sources are the texts of the reference language,
+	// which translators translate from. They are optional.
+	SourceLanguage string
+	Sources        map[string]string
+}
+
+// keys returns the sorted keys of the texts and sources.
+func (c *Catalog) keys() []string {
+	seen := make(map[string]bool, len(c.Texts))
+	var res []string
+	for _, m := range []map[string]string{c.Sources, c.Texts} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				res = append(res, key)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
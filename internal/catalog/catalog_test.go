@@ -0,0 +1,167 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package catalog
+
+import (
+	"bytes"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func testCatalog() *Catalog {
+	return &Catalog{
+		Language:       "de",
+		SourceLanguage: "en",
+		Texts: map[string]string{
+			"title":                           "Feier",
+			"greeting":                        `Hallo {{ firstnames }}!`,
+			"welcome_message":                 "<b>Willkommen</b>\n\"zur\" Feier\t\\o/",
+			"guest_form.select_options_age.0": "Baby",
+			"guest_form.label_no":             "",
+		},
+		Sources: map[string]string{
+			"title":                           "Party",
+			"greeting":                        `Hello {{ firstnames }}!`,
+			"welcome_message":                 "<b>Welcome</b>",
+			"guest_form.select_options_age.0": "Baby",
+			"guest_form.label_no":             "No",
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tt := []struct {
+		format Format
+		encode func(*bytes.Buffer, *Catalog) error
+		decode func(*bytes.Buffer) ([]*Catalog, error)
+	}{
+		{
+			format: FormatJSON,
+			encode: func(b *bytes.Buffer, c *Catalog) error { return EncodeJSON(b, []*Catalog{c}) },
+			decode: func(b *bytes.Buffer) ([]*Catalog, error) { return DecodeJSON(b, "") },
+		},
+		{
+			format: FormatPO,
+			encode: func(b *bytes.Buffer, c *Catalog) error { return EncodePO(b, c) },
+			decode: func(b *bytes.Buffer) ([]*Catalog, error) {
+				c, err := DecodePO(b)
+				return []*Catalog{c}, err
+			},
+		},
+		{
+			format: FormatXLIFF,
+			encode: func(b *bytes.Buffer, c *Catalog) error { return EncodeXLIFF(b, []*Catalog{c}) },
+			decode: func(b *bytes.Buffer) ([]*Catalog, error) { return DecodeXLIFF(b) },
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(string(tc.format), func(t *testing.T) {
+			want := testCatalog()
+			var buf bytes.Buffer
+			if err := tc.encode(&buf, want); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			format, err := DetectFormat("upload", buf.Bytes())
+			if err != nil || format != tc.format {
+				t.Fatalf("detected format %q, %v, want %q", format, err, tc.format)
+			}
+
+			got, err := tc.decode(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != 1 || got[0].Language != want.Language {
+				t.Fatalf("got %d catalogs, want one of language %q", len(got), want.Language)
+			}
+			if !maps.Equal(got[0].Texts, want.Texts) {
+				t.Fatalf("got texts %q, want %q", got[0].Texts, want.Texts)
+			}
+		})
+	}
+}
+
+func TestDecodePO(t *testing.T) {
+	po := `# Translator comment
+msgid ""
+msgstr ""
+"Language: de_AT\n"
+"X-Source-Language: en\n"
+
+msgctxt "title"
+msgid "Party"
+msgstr ""
+"Feier "
+"im Garten"
+
+#, fuzzy
+msgctxt "and"
+msgid "and"
+msgstr "und"
+
+#~ msgctxt "old"
+#~ msgid "Old"
+#~ msgstr "Alt"
+
+msgid "final_message"
+msgstr "Bis bald"
+`
+
+	got, err := DecodePO(strings.NewReader(po))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Language != "de-AT" || got.SourceLanguage != "en" {
+		t.Errorf("got languages %q and %q, want %q and %q", got.Language, got.SourceLanguage, "de-AT", "en")
+	}
+	want := map[string]string{"title": "Feier im Garten", "final_message": "Bis bald"}
+	if !maps.Equal(got.Texts, want) {
+		t.Errorf("got texts %q, want %q", got.Texts, want)
+	}
+
+	if _, err := DecodePO(strings.NewReader("msgctxt \"title\"\nmsgid \"Party\"\nmsgstr \"Feier\"\n")); err == nil {
+		t.Errorf("expected error for missing language")
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	got, err := DecodeJSON(strings.NewReader(`{"title": "Feier"}`), "de")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Language != "de" || got[0].Texts["title"] != "Feier" {
+		t.Errorf("got %+v", got[0])
+	}
+
+	if _, err := DecodeJSON(strings.NewReader(`{"title": "Feier"}`), ""); err == nil {
+		t.Errorf("expected error for flat file without language")
+	}
+	if _, err := DecodeJSON(strings.NewReader(`{"title": "Feier", "de": {"title": "Feier"}}`), "de"); err == nil {
+		t.Errorf("expected error for mixed file")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tt := []struct {
+		filename string
+		data     string
+		want     Format
+		wantErr  bool
+	}{
+		{filename: "de.po", want: FormatPO},
+		{filename: "all.XLF", want: FormatXLIFF},
+		{filename: "de.json", want: FormatJSON},
+		{filename: "upload", data: "\ufeff {}", want: FormatJSON},
+		{filename: "upload", data: "hello", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		got, err := DetectFormat(tc.filename, []byte(tc.data))
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("DetectFormat(%q, %q) = %q, %v, want %q", tc.filename, tc.data, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// EncodeJSON writes catalogs as JSON object of languages, each mapping the
+// keys to the texts:
+//
+//	{"de": {"guest_form.label_yes": "Ja"}}
+func EncodeJSON(w io.Writer, catalogs []*Catalog) error {
+	res := make(map[string]map[string]string, len(catalogs))
+	for _, c := range catalogs {
+		res[c.Language] = c.Texts
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// DecodeJSON reads catalogs written by EncodeJSON. A flat object of keys and
+// texts is read as a single catalog of the language lang.
+func DecodeJSON(r io.Reader, lang string) ([]*Catalog, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	flat := make(map[string]string, len(raw))
+	byLanguage := make(map[string]map[string]string, len(raw))
+	for key, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			flat[key] = text
+			continue
+		}
+		var texts map[string]string
+		if err := json.Unmarshal(value, &texts); err != nil {
+			return nil, fmt.Errorf("%q is neither a text nor a language: %w", key, err)
+		}
+		byLanguage[key] = texts
+	}
+
+	switch {
+	case len(flat) > 0 && len(byLanguage) > 0:
+		return nil, fmt.Errorf("mixed texts and languages")
+	case len(flat) > 0:
+		if lang == "" {
+			return nil, fmt.Errorf("missing language of flat JSON file")
+		}
+		return []*Catalog{{Language: lang, Texts: flat}}, nil
+	}
+
+	res := make([]*Catalog, 0, len(byLanguage))
+	for l, texts := range byLanguage {
+		res = append(res, &Catalog{Language: l, Texts: texts})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Language < res[j].Language })
+	return res, nil
+}
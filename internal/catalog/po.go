@@ -0,0 +1,190 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package catalog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EncodePO writes a catalog as gettext PO file. The key of a text is its
+// message context and the source text its message ID, so translation tools
+// show the text to translate:
+//
+//	msgctxt "guest_form.label_yes"
+//	msgid "Yes"
+//	msgstr "Ja"
+//
+// Texts without a source use their key as message ID.
+func EncodePO(w io.Writer, c *Catalog) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("msgid \"\"\nmsgstr \"\"\n")
+	writePOString(bw, "Content-Type: text/plain; charset=UTF-8\n")
+	writePOString(bw, "Language: "+c.Language+"\n")
+	if c.SourceLanguage != "" {
+		writePOString(bw, "X-Source-Language: "+c.SourceLanguage+"\n")
+	}
+
+	for _, key := range c.keys() {
+		source := c.Sources[key]
+		if source == "" {
+			source = key
+		}
+		bw.WriteString("\nmsgctxt ")
+		writePOString(bw, key)
+		bw.WriteString("msgid ")
+		writePOString(bw, source)
+		bw.WriteString("msgstr ")
+		writePOString(bw, c.Texts[key])
+	}
+	return bw.Flush()
+}
+
+// writePOString writes s as quoted string followed by a line break.
+func writePOString(w *bufio.Writer, s string) {
+	w.WriteString(`"`)
+	for _, r := range s {
+		switch r {
+		case '\\':
+			w.WriteString(`\\`)
+		case '"':
+			w.WriteString(`\"`)
+		case '\n':
+			w.WriteString(`\n`)
+		case '\t':
+			w.WriteString(`\t`)
+		case '\r':
+			w.WriteString(`\r`)
+		default:
+			w.WriteRune(r)
+		}
+	}
+	w.WriteString("\"\n")
+}
+
+// DecodePO reads a gettext PO file. The language is taken from the header and
+// the keys from the message contexts. Messages without context use their
+// message ID as key. Fuzzy and obsolete messages are skipped.
+func DecodePO(r io.Reader) (*Catalog, error) {
+	res := &Catalog{Texts: make(map[string]string), Sources: make(map[string]string)}
+
+	var (
+		entry   poEntry
+		field   *string
+		lineNum int
+	)
+	flush := func() {
+		defer func() { entry = poEntry{} }()
+		if !entry.hasID || entry.fuzzy {
+			return
+		}
+		if entry.id == "" && !entry.hasCtxt {
+			res.parseHeader(entry.str)
+			return
+		}
+		key := entry.id
+		if entry.hasCtxt {
+			key = entry.ctxt
+		}
+		res.Texts[key] = entry.str
+		if entry.id != key {
+			res.Sources[key] = entry.id
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#,"):
+			if entry.hasID {
+				flush()
+			}
+			entry.fuzzy = strings.Contains(line, "fuzzy")
+			continue
+		case strings.HasPrefix(line, "#"):
+			// NOTE: obsolete messages start with "#~" and are skipped
+			// along with all other comments.
+			continue
+		case strings.HasPrefix(line, `"`):
+			if field == nil {
+				return nil, fmt.Errorf("line %d: unexpected string", lineNum)
+			}
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			*field += s
+			continue
+		}
+
+		keyword, value, _ := strings.Cut(line, " ")
+		s, err := strconv.Unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		switch keyword {
+		case "msgctxt":
+			if entry.hasID {
+				flush()
+			}
+			entry.hasCtxt = true
+			entry.ctxt = s
+			field = &entry.ctxt
+		case "msgid":
+			if entry.hasID {
+				flush()
+			}
+			entry.hasID = true
+			entry.id = s
+			field = &entry.id
+		case "msgstr", "msgstr[0]":
+			entry.str = s
+			field = &entry.str
+		default:
+			// NOTE: plural forms other than the first and msgid_plural are
+			// not used by translations.
+			field = new(string)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if res.Language == "" {
+		return nil, fmt.Errorf("missing language in the header")
+	}
+	return res, nil
+}
+
+type poEntry struct {
+	ctxt, id, str  string
+	hasCtxt, hasID bool
+	fuzzy          bool
+}
+
+// parseHeader reads the languages from the header of a PO file.
+func (c *Catalog) parseHeader(header string) {
+	for _, line := range strings.Split(header, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(name) {
+		case "Language":
+			c.Language = strings.ReplaceAll(strings.TrimSpace(value), "_", "-")
+		case "X-Source-Language":
+			c.SourceLanguage = strings.TrimSpace(value)
+		}
+	}
+}
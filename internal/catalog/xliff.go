@@ -0,0 +1,100 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package catalog
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string      `xml:"version,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string      `xml:"original,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr,omitempty"`
+	Datatype       string      `xml:"datatype,attr"`
+	Units          []xliffUnit `xml:"body>trans-unit"`
+}
+
+type xliffUnit struct {
+	ID     string       `xml:"id,attr"`
+	Source string       `xml:"source"`
+	Target *xliffTarget `xml:"target"`
+}
+
+type xliffTarget struct {
+	Text string `xml:",chardata"`
+}
+
+// EncodeXLIFF writes catalogs as XLIFF 1.2 document with one file per
+// language. The keys of the texts are the IDs of the translation units.
+func EncodeXLIFF(w io.Writer, catalogs []*Catalog) error {
+	doc := xliffDocument{Version: "1.2"}
+	for _, c := range catalogs {
+		f := xliffFile{
+			Original:       "translations",
+			SourceLanguage: c.SourceLanguage,
+			TargetLanguage: c.Language,
+			Datatype:       "plaintext",
+		}
+		if f.SourceLanguage == "" {
+			f.SourceLanguage = c.Language
+		}
+		for _, key := range c.keys() {
+			f.Units = append(f.Units, xliffUnit{
+				ID:     key,
+				Source: c.Sources[key],
+				Target: &xliffTarget{Text: c.Texts[key]},
+			})
+		}
+		doc.Files = append(doc.Files, f)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// DecodeXLIFF reads an XLIFF 1.2 document. Every file is read as catalog of
+// its target language.
+func DecodeXLIFF(r io.Reader) ([]*Catalog, error) {
+	var doc xliffDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	res := make([]*Catalog, 0, len(doc.Files))
+	for i, f := range doc.Files {
+		if f.TargetLanguage == "" {
+			return nil, fmt.Errorf("file %d: missing target language", i+1)
+		}
+		c := &Catalog{
+			Language:       f.TargetLanguage,
+			SourceLanguage: f.SourceLanguage,
+			Texts:          make(map[string]string, len(f.Units)),
+			Sources:        make(map[string]string, len(f.Units)),
+		}
+		for _, u := range f.Units {
+			c.Sources[u.ID] = u.Source
+			if u.Target != nil {
+				c.Texts[u.ID] = u.Target.Text
+			}
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
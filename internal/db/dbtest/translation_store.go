@@ -0,0 +1,96 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package dbtest contains the tests every implementation of the stores must
+// pass.
+package dbtest
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/quixsi/core/internal/db"
+	"github.com/quixsi/core/internal/model"
+)
+
+// TranslationStore tests the changes of languages. open returns a store of
+// the same data every time it is called, starting without languages, so the
+// changes are checked to be persisted.
+func TranslationStore(t *testing.T, open func() db.TranslationStore) {
+	ctx := context.Background()
+	store := open()
+
+	wantLanguages := func(want ...string) {
+		t.Helper()
+		got, err := store.ListLanguages(ctx)
+		if err != nil {
+			t.Fatalf("ListLanguages: unexpected error: %v", err)
+		}
+		if len(got) != len(want) || (len(want) > 0 && !slices.Equal(got, want)) {
+			t.Fatalf("ListLanguages = %q, want %q", got, want)
+		}
+	}
+	wantLocale := func(lang, want string) {
+		t.Helper()
+		got, err := store.ByLanguage(ctx, lang)
+		if err != nil {
+			t.Fatalf("ByLanguage(%q): unexpected error: %v", lang, err)
+		}
+		if got.Locale != want {
+			t.Errorf("ByLanguage(%q) has locale %q, want %q", lang, got.Locale, want)
+		}
+	}
+
+	wantLanguages()
+	if _, err := store.ByLanguage(ctx, "en"); err == nil {
+		t.Error("ByLanguage: expected an error for a missing language")
+	}
+
+	for lang, locale := range map[string]string{"en": "en-GB", "de": "de-DE"} {
+		if err := store.CreateLanguage(ctx, lang, &model.Translation{Locale: locale}); err != nil {
+			t.Fatalf("CreateLanguage(%q): unexpected error: %v", lang, err)
+		}
+	}
+	if err := store.CreateLanguage(ctx, "de", &model.Translation{Locale: "de-CH"}); err == nil {
+		t.Error("CreateLanguage: expected an error for an existing language")
+	}
+	wantLanguages("de", "en")
+	wantLocale("de", "de-DE")
+
+	// NOTE: no language is updated if one of them is missing.
+	err := store.UpdateLanguages(ctx, map[string]*model.Translation{"de": {Locale: "de-AT"}, "fr": {Locale: "fr-FR"}})
+	if err == nil {
+		t.Error("UpdateLanguages: expected an error for a missing language")
+	}
+	wantLanguages("de", "en")
+	wantLocale("de", "de-DE")
+	if err := store.UpdateLanguages(ctx, map[string]*model.Translation{"de": {Locale: "de-AT"}}); err != nil {
+		t.Fatalf("UpdateLanguages: unexpected error: %v", err)
+	}
+	wantLocale("de", "de-AT")
+
+	if err := store.RenameLanguage(ctx, "de", "en"); err == nil {
+		t.Error("RenameLanguage: expected an error for an existing language")
+	}
+	if err := store.RenameLanguage(ctx, "fr", "es"); err == nil {
+		t.Error("RenameLanguage: expected an error for a missing language")
+	}
+	if err := store.RenameLanguage(ctx, "de", "de-AT"); err != nil {
+		t.Fatalf("RenameLanguage: unexpected error: %v", err)
+	}
+	wantLanguages("de-AT", "en")
+	wantLocale("de-AT", "de-AT")
+
+	if err := store.DeleteLanguage(ctx, "fr"); err == nil {
+		t.Error("DeleteLanguage: expected an error for a missing language")
+	}
+	if err := store.DeleteLanguage(ctx, "en"); err != nil {
+		t.Fatalf("DeleteLanguage: unexpected error: %v", err)
+	}
+	wantLanguages("de-AT")
+
+	store = open()
+	wantLanguages("de-AT")
+	wantLocale("de-AT", "de-AT")
+}
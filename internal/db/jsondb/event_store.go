@@ -14,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/quixsi/core/internal/fsutil"
 	"github.com/quixsi/core/internal/model"
 )
 
@@ -45,22 +46,66 @@ func (e *EventStore) GetEvent(ctx context.Context) (*model.Event, error) {
 	defer span.AddEvent("RUlock")
 	defer e.mu.Unlock()
 
-	return e.event, nil
+	return copyEvent(e.event)
 }
 
-func (e *EventStore) UpdateEvent(ctx context.Context, _ *model.Event) error {
+func (e *EventStore) UpdateEvent(ctx context.Context, event *model.Event) error {
 	var span trace.Span
-	_, span = tracer.Start(ctx, "UpdateEvent")
+	ctx, span = tracer.Start(ctx, "UpdateEvent")
 	defer span.End()
 
-	span.AddEvent("RLock")
-	e.mu.RLock()
-	defer span.AddEvent("RUnlock")
-	defer e.mu.RUnlock()
+	if event == nil || event.Location == nil {
+		err := errors.New("event location is required")
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("Lock")
+	e.mu.Lock()
+	defer span.AddEvent("Unlock")
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	event.UpdatedAt = &now
+	stored, err := copyEvent(event)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	e.event = stored
 
-	err := errors.New("not implemented")
-	span.RecordError(err)
-	return err
+	return e.saveToFile(ctx)
+}
+
+// copyEvent deep copies an event, so callers can not modify the stored one.
+func copyEvent(event *model.Event) (*model.Event, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	res := &model.Event{}
+	return res, json.Unmarshal(data, res)
+}
+
+// saveToFile saves the current event to the JSON file. The file is replaced
+// atomically, as it also holds the gift claims and the theme.
+func (e *EventStore) saveToFile(ctx context.Context) error {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "SaveToFile")
+	defer span.End()
+
+	fileData, err := json.MarshalIndent(e.event, "", "  ")
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	err = fsutil.WriteFileAtomic(e.filename, fileData, 0644)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
 }
 
 func (e *EventStore) loadFromFile() error {
@@ -14,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/quixsi/core/internal/fsutil"
 	"github.com/quixsi/core/internal/model"
 )
 
@@ -63,7 +64,8 @@ func (g *GuestStore) CreateGuest(ctx context.Context, guest *model.Guest) (uuid.
 	span.AddEvent("create new guest")
 	now := time.Now()
 	guest.CreatedAt = &now
-	g.guests[guest.ID] = guest
+	stored := *guest
+	g.guests[guest.ID] = &stored
 
 	span.AddEvent("save to file")
 	// Save the updated store to the JSON file
@@ -101,7 +103,8 @@ func (g *GuestStore) UpdateGuest(ctx context.Context, guest *model.Guest) error
 	now := time.Now()
 	guest.UpdatedAt = &now
 	// Update the guest in the store
-	g.guests[guest.ID] = guest
+	stored := *guest
+	g.guests[guest.ID] = &stored
 
 	// Save the updated store to the JSON file
 	if err := g.saveToFile(ctx); err != nil {
@@ -124,7 +127,8 @@ func (g *GuestStore) ListGuests(ctx context.Context) ([]*model.Guest, error) {
 
 	guestList := make([]*model.Guest, 0, len(g.guests))
 	for _, guest := range g.guests {
-		guestList = append(guestList, guest)
+		res := *guest
+		guestList = append(guestList, &res)
 	}
 
 	return guestList, nil
@@ -148,7 +152,8 @@ func (g *GuestStore) GetGuestByID(ctx context.Context, id uuid.UUID) (*model.Gue
 		return nil, err
 	}
 
-	return guest, nil
+	res := *guest
+	return &res, nil
 }
 
 // saveToFile saves the current guest store to the JSON file.
@@ -163,7 +168,7 @@ func (g *GuestStore) saveToFile(ctx context.Context) error {
 		return err
 	}
 
-	err = os.WriteFile(g.filename, fileData, 0644)
+	err = fsutil.WriteFileAtomic(g.filename, fileData, 0644)
 	if err != nil {
 		span.RecordError(err)
 		return err
@@ -13,12 +13,13 @@ import (
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/quixsi/core/internal/fsutil"
 	"github.com/quixsi/core/internal/model"
 )
 
 func NewInvitationStore(filename string) (*InvitationStore, error) {
 	store := &InvitationStore{
-		invitations: make(map[uuid.UUID][]uuid.UUID),
+		invitations: make(map[uuid.UUID]*model.Invitation),
 		filename:    filename,
 	}
 
@@ -30,7 +31,7 @@ func NewInvitationStore(filename string) (*InvitationStore, error) {
 
 type InvitationStore struct {
 	mu          sync.RWMutex
-	invitations map[uuid.UUID][]uuid.UUID
+	invitations map[uuid.UUID]*model.Invitation
 	filename    string
 }
 
@@ -44,16 +45,14 @@ func (i *InvitationStore) GetInvitationByID(ctx context.Context, inviteID uuid.U
 	defer span.AddEvent("RUnlock")
 	defer i.mu.RUnlock()
 
-	guestIDs, ok := i.invitations[inviteID]
+	invite, ok := i.invitations[inviteID]
 	if !ok {
 		err := fmt.Errorf("could not find invite with id: %s", inviteID)
 		span.RecordError(err)
 		return nil, err
 	}
-	return &model.Invitation{
-		ID:       inviteID,
-		GuestIDs: guestIDs,
-	}, nil
+	res := *invite
+	return &res, nil
 }
 
 func (i *InvitationStore) CreateInvitation(ctx context.Context, guestIDs ...uuid.UUID) (*model.Invitation, error) {
@@ -71,14 +70,20 @@ func (i *InvitationStore) CreateInvitation(ctx context.Context, guestIDs ...uuid
 		span.RecordError(err)
 		return nil, err
 	}
-	i.invitations[id] = guestIDs
+	invite := &model.Invitation{
+		ID:       id,
+		GuestIDs: guestIDs,
+	}
+	if err := invite.Validate(); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	i.invitations[id] = invite
 	if err := i.saveToFile(ctx); err != nil {
 		return nil, err
 	}
-	return &model.Invitation{
-		ID:       id,
-		GuestIDs: guestIDs,
-	}, nil
+	res := *invite
+	return &res, nil
 }
 
 func (i *InvitationStore) UpdateInvitation(ctx context.Context, invite *model.Invitation) error {
@@ -86,6 +91,11 @@ func (i *InvitationStore) UpdateInvitation(ctx context.Context, invite *model.In
 	ctx, span = tracer.Start(ctx, "UpdateInvitation")
 	defer span.End()
 
+	if err := invite.Validate(); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
 	span.AddEvent("Lock")
 	i.mu.Lock()
 	defer span.AddEvent("Unlock")
@@ -96,7 +106,8 @@ func (i *InvitationStore) UpdateInvitation(ctx context.Context, invite *model.In
 		span.RecordError(err)
 		return err
 	}
-	i.invitations[invite.ID] = invite.GuestIDs
+	update := *invite
+	i.invitations[invite.ID] = &update
 	if err := i.saveToFile(ctx); err != nil {
 		return err
 	}
@@ -114,11 +125,9 @@ func (i *InvitationStore) ListInvitations(ctx context.Context) ([]*model.Invitat
 	defer i.mu.RUnlock()
 
 	var res []*model.Invitation
-	for inviteID, guestIDs := range i.invitations {
-		res = append(res, &model.Invitation{
-			ID:       inviteID,
-			GuestIDs: guestIDs,
-		})
+	for _, invite := range i.invitations {
+		inv := *invite
+		res = append(res, &inv)
 	}
 	return res, nil
 }
@@ -135,7 +144,7 @@ func (i *InvitationStore) saveToFile(ctx context.Context) error {
 		return err
 	}
 
-	err = os.WriteFile(i.filename, fileData, 0644)
+	err = fsutil.WriteFileAtomic(i.filename, fileData, 0644)
 	if err != nil {
 		span.RecordError(err)
 		return err
@@ -158,5 +167,18 @@ func (i *InvitationStore) loadFromFile() error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	return json.Unmarshal(fileData, &i.invitations)
+	if err := json.Unmarshal(fileData, &i.invitations); err == nil {
+		return nil
+	}
+
+	// NOTE: older files map the invitation ID directly to its guest IDs.
+	legacy := make(map[uuid.UUID][]uuid.UUID)
+	if err := json.Unmarshal(fileData, &legacy); err != nil {
+		return err
+	}
+	i.invitations = make(map[uuid.UUID]*model.Invitation, len(legacy))
+	for id, guestIDs := range legacy {
+		i.invitations[id] = &model.Invitation{ID: id, GuestIDs: guestIDs}
+	}
+	return nil
 }
@@ -0,0 +1,87 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package jsondb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestInvitationStore_Load(t *testing.T) {
+	inviteID := uuid.MustParse("ba20785f-8c7b-442e-935a-1cb58c41b92a")
+	guestIDs := []uuid.UUID{
+		uuid.MustParse("42a7b4d3-25c6-431f-8930-f611c16103e6"),
+		uuid.MustParse("39a502ac-ba10-430d-99ac-e0955eccb73b"),
+	}
+
+	tt := []struct {
+		name          string
+		data          string
+		wantMaxGuests int
+		wantErr       bool
+	}{
+		{
+			name: "invitations",
+			data: `{"ba20785f-8c7b-442e-935a-1cb58c41b92a": {"ID": "ba20785f-8c7b-442e-935a-1cb58c41b92a", ` +
+				`"GuestIDs": ["42a7b4d3-25c6-431f-8930-f611c16103e6", "39a502ac-ba10-430d-99ac-e0955eccb73b"], "max_guests": 4}}`,
+			wantMaxGuests: 4,
+		},
+		{
+			name: "legacy guest IDs",
+			data: `{"ba20785f-8c7b-442e-935a-1cb58c41b92a": ["42a7b4d3-25c6-431f-8930-f611c16103e6", "39a502ac-ba10-430d-99ac-e0955eccb73b"]}`,
+		},
+		{
+			name:    "invalid",
+			data:    `{"ba20785f-8c7b-442e-935a-1cb58c41b92a": 42}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "invitations.json")
+			if err := os.WriteFile(filename, []byte(tc.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			store, err := NewInvitationStore(filename)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			invite, err := store.GetInvitationByID(context.Background(), inviteID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if invite.ID != inviteID || !slices.Equal(invite.GuestIDs, guestIDs) {
+				t.Errorf("got invitation %s with guests %v", invite.ID, invite.GuestIDs)
+			}
+			if invite.MaxGuests != tc.wantMaxGuests {
+				t.Errorf("got max guests %d, want %d", invite.MaxGuests, tc.wantMaxGuests)
+			}
+
+			// NOTE: legacy files are written in the current format.
+			if err := store.UpdateInvitation(context.Background(), invite); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			reopened, err := NewInvitationStore(filename)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, err := reopened.GetInvitationByID(context.Background(), inviteID); err != nil || !slices.Equal(got.GuestIDs, guestIDs) {
+				t.Errorf("reopened store: got %v, %v", got, err)
+			}
+		})
+	}
+}
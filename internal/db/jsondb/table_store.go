@@ -0,0 +1,204 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package jsondb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/fsutil"
+	"github.com/quixsi/core/internal/model"
+)
+
+// TableStore is an implementation of the TableStore interface
+// that stores the seating plan in a JSON file.
+type TableStore struct {
+	filename string
+	mu       sync.RWMutex
+	tables   map[uuid.UUID]*model.Table
+}
+
+// NewTableStore creates a new TableStore instance.
+func NewTableStore(filename string) (*TableStore, error) {
+	store := &TableStore{
+		filename: filename,
+		tables:   make(map[uuid.UUID]*model.Table),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// CreateTable adds a new table to the store and stores it in the JSON file.
+func (t *TableStore) CreateTable(ctx context.Context, table *model.Table) (uuid.UUID, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "CreateTable")
+	defer span.End()
+
+	span.AddEvent("Lock")
+	t.mu.Lock()
+	defer span.AddEvent("Unlock")
+	defer t.mu.Unlock()
+
+	if table.ID == uuid.Nil {
+		table.ID = uuid.New()
+	}
+
+	if _, ok := t.tables[table.ID]; ok {
+		err := errors.New("table already exists")
+		span.RecordError(err)
+		return uuid.Nil, err
+	}
+	now := time.Now()
+	table.CreatedAt = &now
+	t.tables[table.ID] = copyTable(table)
+
+	if err := t.saveToFile(ctx); err != nil {
+		return uuid.Nil, err
+	}
+	return table.ID, nil
+}
+
+// UpdateTable updates an existing table in the store and JSON file.
+func (t *TableStore) UpdateTable(ctx context.Context, table *model.Table) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "UpdateTable")
+	defer span.End()
+
+	if table.ID == uuid.Nil {
+		err := errors.New("table ID is required for updating")
+		span.RecordError(err)
+		return err
+	}
+
+	span.AddEvent("Lock")
+	t.mu.Lock()
+	defer span.AddEvent("Unlock")
+	defer t.mu.Unlock()
+
+	if _, ok := t.tables[table.ID]; !ok {
+		err := errors.New("table not found")
+		span.RecordError(err)
+		return err
+	}
+
+	now := time.Now()
+	table.UpdatedAt = &now
+	t.tables[table.ID] = copyTable(table)
+
+	return t.saveToFile(ctx)
+}
+
+// DeleteTable deletes a table from the store and JSON file.
+func (t *TableStore) DeleteTable(ctx context.Context, tableID uuid.UUID) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "DeleteTable")
+	defer span.End()
+
+	span.AddEvent("Lock")
+	t.mu.Lock()
+	defer span.AddEvent("Unlock")
+	defer t.mu.Unlock()
+
+	if _, ok := t.tables[tableID]; !ok {
+		err := errors.New("table not found")
+		span.RecordError(err)
+		return err
+	}
+	delete(t.tables, tableID)
+
+	return t.saveToFile(ctx)
+}
+
+// ListTables returns a list of all tables in the store.
+func (t *TableStore) ListTables(ctx context.Context) ([]*model.Table, error) {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "ListTables")
+	defer span.End()
+
+	span.AddEvent("RLock")
+	t.mu.RLock()
+	defer span.AddEvent("RUnlock")
+	defer t.mu.RUnlock()
+
+	tables := make([]*model.Table, 0, len(t.tables))
+	for _, table := range t.tables {
+		tables = append(tables, copyTable(table))
+	}
+	return tables, nil
+}
+
+// GetTableByID retrieves a table by ID from the store.
+func (t *TableStore) GetTableByID(ctx context.Context, tableID uuid.UUID) (*model.Table, error) {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "GetTableByID")
+	defer span.End()
+
+	span.AddEvent("RLock")
+	t.mu.RLock()
+	defer span.AddEvent("RUnlock")
+	defer t.mu.RUnlock()
+
+	table, ok := t.tables[tableID]
+	if !ok {
+		err := errors.New("table not found")
+		span.RecordError(err)
+		return nil, err
+	}
+	return copyTable(table), nil
+}
+
+// copyTable copies a table, so callers can not modify the stored one.
+func copyTable(table *model.Table) *model.Table {
+	res := *table
+	res.GuestIDs = slices.Clone(table.GuestIDs)
+	return &res
+}
+
+// saveToFile saves the current table store to the JSON file.
+func (t *TableStore) saveToFile(ctx context.Context) error {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "SaveToFile")
+	defer span.End()
+
+	fileData, err := json.MarshalIndent(t.tables, "", "  ")
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := fsutil.WriteFileAtomic(t.filename, fileData, 0644); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// loadFromFile loads the tables from the JSON file into the store.
+func (t *TableStore) loadFromFile() error {
+	if _, err := os.Stat(t.filename); os.IsNotExist(err) {
+		// File does not exist, no tables to load
+		return nil
+	}
+
+	fileData, err := os.ReadFile(t.filename)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return json.Unmarshal(fileData, &t.tables)
+}
@@ -7,12 +7,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"sort"
 	"sync"
 
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/quixsi/core/internal/fsutil"
 	"github.com/quixsi/core/internal/model"
 )
 
@@ -70,7 +74,8 @@ func (t *TranslationStore) ByLanguage(ctx context.Context, l string) (*model.Tra
 		span.RecordError(err)
 		return nil, err
 	}
-	return &lang, nil
+	res := copyTranslation(&lang)
+	return &res, nil
 }
 
 func (t *TranslationStore) loadFromFile() error {
@@ -90,10 +95,155 @@ func (t *TranslationStore) loadFromFile() error {
 	return json.Unmarshal(fileData, &t.byLanguage)
 }
 
-func (t *TranslationStore) CreateLanguage(context.Context, string, *model.Translation) error {
-	return errors.New("not implemented")
+// CreateLanguage adds a new language to the store and stores it in the JSON
+// file.
+func (t *TranslationStore) CreateLanguage(ctx context.Context, lang string, translation *model.Translation) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "CreateLanguage")
+	defer span.End()
+
+	span.AddEvent("Lock")
+	t.mu.Lock()
+	defer span.AddEvent("Unlock")
+	defer t.mu.Unlock()
+
+	if _, ok := t.byLanguage[lang]; ok {
+		err := errors.New("language already exists")
+		span.RecordError(err)
+		return err
+	}
+	t.byLanguage[lang] = copyTranslation(translation)
+
+	if err := t.saveToFile(ctx); err != nil {
+		delete(t.byLanguage, lang)
+		return err
+	}
+	return nil
 }
 
-func (t *TranslationStore) UpdateLanguages(context.Context, map[string]*model.Translation) error {
-	return errors.New("not implemented")
+// UpdateLanguages replaces the translations of existing languages and stores
+// them in the JSON file. Either all or none of the languages are updated.
+func (t *TranslationStore) UpdateLanguages(ctx context.Context, translations map[string]*model.Translation) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "UpdateLanguages")
+	defer span.End()
+
+	span.AddEvent("Lock")
+	t.mu.Lock()
+	defer span.AddEvent("Unlock")
+	defer t.mu.Unlock()
+
+	previous := make(map[string]model.Translation, len(translations))
+	for lang := range translations {
+		p, ok := t.byLanguage[lang]
+		if !ok {
+			err := fmt.Errorf("missing translation for language %q", lang)
+			span.RecordError(err)
+			return err
+		}
+		previous[lang] = p
+	}
+	for lang, translation := range translations {
+		t.byLanguage[lang] = copyTranslation(translation)
+	}
+
+	if err := t.saveToFile(ctx); err != nil {
+		for lang, p := range previous {
+			t.byLanguage[lang] = p
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteLanguage removes a language from the store and the JSON file.
+func (t *TranslationStore) DeleteLanguage(ctx context.Context, lang string) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "DeleteLanguage")
+	defer span.End()
+
+	span.AddEvent("Lock")
+	t.mu.Lock()
+	defer span.AddEvent("Unlock")
+	defer t.mu.Unlock()
+
+	translation, ok := t.byLanguage[lang]
+	if !ok {
+		err := errors.New("missing translation")
+		span.RecordError(err)
+		return err
+	}
+	delete(t.byLanguage, lang)
+
+	if err := t.saveToFile(ctx); err != nil {
+		t.byLanguage[lang] = translation
+		return err
+	}
+	return nil
+}
+
+// RenameLanguage changes the key of a language, e.g. from "de" to "de-AT",
+// in the store and the JSON file.
+func (t *TranslationStore) RenameLanguage(ctx context.Context, from, to string) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "RenameLanguage")
+	defer span.End()
+
+	span.AddEvent("Lock")
+	t.mu.Lock()
+	defer span.AddEvent("Unlock")
+	defer t.mu.Unlock()
+
+	translation, ok := t.byLanguage[from]
+	if !ok {
+		err := errors.New("missing translation")
+		span.RecordError(err)
+		return err
+	}
+	if _, ok := t.byLanguage[to]; ok {
+		err := errors.New("language already exists")
+		span.RecordError(err)
+		return err
+	}
+	delete(t.byLanguage, from)
+	t.byLanguage[to] = translation
+
+	if err := t.saveToFile(ctx); err != nil {
+		delete(t.byLanguage, to)
+		t.byLanguage[from] = translation
+		return err
+	}
+	return nil
+}
+
+// copyTranslation copies a translation, so callers can not modify the stored
+// one.
+func copyTranslation(translation *model.Translation) model.Translation {
+	res := *translation
+	res.GuestForm.SelectOptionsAge = slices.Clone(translation.GuestForm.SelectOptionsAge)
+	res.GuestForm.SelectOptionsDiet = slices.Clone(translation.GuestForm.SelectOptionsDiet)
+	res.GuestForm.SelectOptionsInvStatus = slices.Clone(translation.GuestForm.SelectOptionsInvStatus)
+	res.GuestForm.OptionsDietaryTags = maps.Clone(translation.GuestForm.OptionsDietaryTags)
+	res.GuestForm.OptionsAllergens = maps.Clone(translation.GuestForm.OptionsAllergens)
+	return res
+}
+
+// saveToFile saves the translations to the JSON file. The file is replaced
+// atomically, so it is never left half written.
+func (t *TranslationStore) saveToFile(ctx context.Context) error {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "SaveToFile")
+	defer span.End()
+
+	fileData, err := json.MarshalIndent(t.byLanguage, "", "  ")
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := fsutil.WriteFileAtomic(t.filename, fileData, 0644); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
 }
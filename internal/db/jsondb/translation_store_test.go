@@ -0,0 +1,23 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package jsondb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/quixsi/core/internal/db"
+	"github.com/quixsi/core/internal/db/dbtest"
+)
+
+func TestTranslationStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "translations.json")
+	dbtest.TranslationStore(t, func() db.TranslationStore {
+		store, err := NewTranslationStore(filename)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return store
+	})
+}
@@ -56,6 +56,10 @@ func (i *InvitationStore) CreateInvitation(ctx context.Context, guestIDs ...uuid
 		ID:       id,
 		GuestIDs: guestIDs,
 	}
+	if err := invite.Validate(); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
 	return invite, i.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(bucketInvitation))
 		res := bucket.Get(id[:])
@@ -76,6 +80,11 @@ func (i *InvitationStore) UpdateInvitation(ctx context.Context, invite *model.In
 	_, span = tracer.Start(ctx, "UpdateInvitation")
 	defer span.End()
 
+	if err := invite.Validate(); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
 	span.AddEvent("Lock")
 	defer span.AddEvent("Unlock")
 
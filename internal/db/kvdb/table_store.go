@@ -0,0 +1,134 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package kvdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/model"
+)
+
+const bucketTable = "table_store"
+
+func NewTableStore(db *bolt.DB) (*TableStore, error) {
+	return &TableStore{db: db}, db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucketTable))
+		return err
+	})
+}
+
+type TableStore struct {
+	db *bolt.DB
+}
+
+func (t *TableStore) CreateTable(ctx context.Context, table *model.Table) (uuid.UUID, error) {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "CreateTable")
+	defer span.End()
+
+	if table.ID == uuid.Nil {
+		span.AddEvent("uuid is nil, generate a new id")
+		table.ID = uuid.New()
+	}
+	now := time.Now()
+	table.CreatedAt = &now
+
+	j, err := json.Marshal(table)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	span.AddEvent("Update bucket")
+	return table.ID, t.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketTable))
+		if bucket.Get(table.ID[:]) != nil {
+			return errors.New("table already exists")
+		}
+		return bucket.Put(table.ID[:], j)
+	})
+}
+
+func (t *TableStore) UpdateTable(ctx context.Context, table *model.Table) error {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "UpdateTable")
+	defer span.End()
+
+	if table.ID == uuid.Nil {
+		err := errors.New("table ID is required for updating")
+		span.RecordError(err)
+		return err
+	}
+	now := time.Now()
+	table.UpdatedAt = &now
+
+	j, err := json.Marshal(table)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("Update bucket")
+	return t.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketTable))
+		if bucket.Get(table.ID[:]) == nil {
+			return errors.New("table not found")
+		}
+		return bucket.Put(table.ID[:], j)
+	})
+}
+
+func (t *TableStore) DeleteTable(ctx context.Context, tableID uuid.UUID) error {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "DeleteTable")
+	defer span.End()
+
+	span.AddEvent("Update bucket")
+	return t.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucketTable)).Delete(tableID[:])
+	})
+}
+
+func (t *TableStore) ListTables(ctx context.Context) ([]*model.Table, error) {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "ListTables")
+	defer span.End()
+
+	span.AddEvent("View bucket")
+	var tables []*model.Table
+	return tables, t.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucketTable)).ForEach(func(_, v []byte) error {
+			table := &model.Table{}
+			if err := json.Unmarshal(v, table); err != nil {
+				span.RecordError(err)
+				return err
+			}
+			tables = append(tables, table)
+			return nil
+		})
+	})
+}
+
+func (t *TableStore) GetTableByID(ctx context.Context, tableID uuid.UUID) (*model.Table, error) {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "GetTableByID")
+	defer span.End()
+
+	span.AddEvent("View bucket")
+	table := &model.Table{}
+	return table, t.db.View(func(tx *bolt.Tx) error {
+		res := tx.Bucket([]byte(bucketTable)).Get(tableID[:])
+		if res == nil {
+			err := errors.New("table not found")
+			span.RecordError(err)
+			return err
+		}
+		return json.Unmarshal(res, table)
+	})
+}
@@ -49,6 +49,12 @@ func (t *TranslationStore) UpdateLanguages(ctx context.Context, translations map
 	return t.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(bucketTranslation))
 		for lang, translation := range data {
+			if bucket.Get([]byte(lang)) == nil {
+				err := fmt.Errorf("missing translation for language %q", lang)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return err
+			}
 			if err := bucket.Put([]byte(lang), translation); err != nil {
 				err := fmt.Errorf("update translation for language %q", lang)
 				span.RecordError(err)
@@ -104,6 +110,9 @@ func (t *TranslationStore) ByLanguage(ctx context.Context, l string) (*model.Tra
 func (t *TranslationStore) CreateLanguage(_ context.Context, key string, translation *model.Translation) error {
 	return t.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(bucketTranslation))
+		if bucket.Get([]byte(key)) != nil {
+			return errors.New("language already exists")
+		}
 		val, err := json.Marshal(translation)
 		if err != nil {
 			return err
@@ -111,3 +120,46 @@ func (t *TranslationStore) CreateLanguage(_ context.Context, key string, transla
 		return bucket.Put([]byte(key), val)
 	})
 }
+
+func (t *TranslationStore) DeleteLanguage(ctx context.Context, lang string) error {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "DeleteLanguage")
+	defer span.End()
+
+	return t.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketTranslation))
+		if bucket.Get([]byte(lang)) == nil {
+			err := errors.New("missing translation")
+			span.RecordError(err)
+			return err
+		}
+		return bucket.Delete([]byte(lang))
+	})
+}
+
+func (t *TranslationStore) RenameLanguage(ctx context.Context, from, to string) error {
+	var span trace.Span
+	_, span = tracer.Start(ctx, "RenameLanguage")
+	defer span.End()
+
+	return t.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketTranslation))
+		val := bucket.Get([]byte(from))
+		if val == nil {
+			err := errors.New("missing translation")
+			span.RecordError(err)
+			return err
+		}
+		if bucket.Get([]byte(to)) != nil {
+			err := errors.New("language already exists")
+			span.RecordError(err)
+			return err
+		}
+		// NOTE: values are only valid during the transaction, so they are
+		// copied before the key is deleted.
+		if err := bucket.Put([]byte(to), append([]byte(nil), val...)); err != nil {
+			return err
+		}
+		return bucket.Delete([]byte(from))
+	})
+}
@@ -0,0 +1,40 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package kvdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/quixsi/core/internal/db"
+	"github.com/quixsi/core/internal/db/dbtest"
+)
+
+func TestTranslationStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "party.db")
+	var bdb *bolt.DB
+	t.Cleanup(func() { bdb.Close() })
+
+	dbtest.TranslationStore(t, func() db.TranslationStore {
+		// NOTE: a file can only be opened once, so the database opened
+		// before is closed.
+		if bdb != nil {
+			if err := bdb.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var err error
+		bdb, err = bolt.Open(filename, 0o600, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		store, err := NewTranslationStore(bdb)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return store
+	})
+}
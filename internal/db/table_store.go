@@ -0,0 +1,20 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/quixsi/core/internal/model"
+)
+
+type TableStore interface {
+	CreateTable(context.Context, *model.Table) (uuid.UUID, error)
+	UpdateTable(context.Context, *model.Table) error
+	DeleteTable(context.Context, uuid.UUID) error
+	ListTables(context.Context) ([]*model.Table, error)
+	GetTableByID(context.Context, uuid.UUID) (*model.Table, error)
+}
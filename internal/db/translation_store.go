@@ -12,6 +12,13 @@ import (
 type TranslationStore interface {
 	ListLanguages(context.Context) ([]string, error)
 	ByLanguage(context.Context, string) (*model.Translation, error)
+	// CreateLanguage adds a language. It fails if the key is in use.
 	CreateLanguage(context.Context, string, *model.Translation) error
+	// UpdateLanguages replaces the translations of existing languages. It
+	// fails without changes if one of them does not exist.
 	UpdateLanguages(context.Context, map[string]*model.Translation) error
+	DeleteLanguage(context.Context, string) error
+	// RenameLanguage changes the key of a language. It fails if the new key
+	// is in use.
+	RenameLanguage(ctx context.Context, from, to string) error
 }
@@ -0,0 +1,48 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "event.json")
+
+	for _, data := range []string{`{"name": "first"}`, `{"name": "second"}`} {
+		if err := WriteFileAtomic(name, []byte(data), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("got %q, want %q", got, data)
+		}
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("got mode %v, want %v", info.Mode().Perm(), os.FileMode(0o644))
+	}
+	// NOTE: no temporary files are left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d files, want 1", len(entries))
+	}
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "event.json"), nil, 0o644); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
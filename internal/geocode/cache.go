@@ -0,0 +1,116 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/quixsi/core/internal/fsutil"
+)
+
+// notFoundTTL is how long an address a provider did not find is not looked
+// up again. Found addresses are cached forever.
+const notFoundTTL = 24 * time.Hour
+
+type cacheEntry struct {
+	// Point is nil if the address was not found.
+	Point *Point    `json:"point,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Cache is a provider that remembers the results of another one, so every
+// address is looked up only once. Failed lookups other than ErrNotFound are
+// not cached.
+type Cache struct {
+	provider Provider
+	// file is the JSON file the results are kept in, or "" to keep them in
+	// memory only.
+	file string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// NewCache returns a cache of provider's results, reading and writing them
+// to file unless it is "".
+func NewCache(provider Provider, file string) (*Cache, error) {
+	c := &Cache{
+		provider: provider,
+		file:     file,
+		now:      time.Now,
+		entries:  make(map[string]*cacheEntry),
+	}
+	if file == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return c, nil
+}
+
+func (c *Cache) Geocode(ctx context.Context, address Address) (Point, error) {
+	if address.IsZero() {
+		return Point{}, ErrEmptyAddress
+	}
+	key := address.key()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && entry.Point != nil {
+		return *entry.Point, nil
+	}
+	if ok && c.now().Sub(entry.Time) < notFoundTTL {
+		return Point{}, fmt.Errorf("%w: %s", ErrNotFound, address)
+	}
+
+	p, err := c.provider.Geocode(ctx, address)
+	switch {
+	case err == nil:
+		entry = &cacheEntry{Point: &p, Time: c.now()}
+	case errors.Is(err, ErrNotFound):
+		entry = &cacheEntry{Time: c.now()}
+	default:
+		return Point{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	if err := c.save(); err != nil {
+		return Point{}, fmt.Errorf("could not save geocoding cache: %w", err)
+	}
+	if entry.Point == nil {
+		return Point{}, fmt.Errorf("%w: %s", ErrNotFound, address)
+	}
+	return p, nil
+}
+
+// save writes the entries to the file of the cache. The caller must hold mu.
+func (c *Cache) save() error {
+	if c.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(c.file, data, 0o644)
+}
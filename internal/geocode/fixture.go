@@ -0,0 +1,59 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Fixture is a provider that knows a fixed set of addresses, e.g. for tests
+// or servers without internet access. Addresses match regardless of case
+// and spacing.
+type Fixture struct {
+	points map[string]Point
+}
+
+// NewFixture returns a provider for the given addresses and their points.
+func NewFixture(points map[Address]Point) *Fixture {
+	f := &Fixture{points: make(map[string]Point, len(points))}
+	for a, p := range points {
+		f.points[a.key()] = p
+	}
+	return f
+}
+
+// LoadFixture reads a JSON file mapping addresses as returned by
+// Address.String to points, e.g.
+//
+//	{"Unter den Linden 77, 10117 Berlin, Germany": {"latitude": 52.516, "longitude": 13.381}}
+func LoadFixture(name string) (*Fixture, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var points map[string]Point
+	if err := json.Unmarshal(data, &points); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	f := &Fixture{points: make(map[string]Point, len(points))}
+	for a, p := range points {
+		// NOTE: the keys are normalized like Address.key.
+		f.points[Address{Street: a}.key()] = p
+	}
+	return f, nil
+}
+
+func (f *Fixture) Geocode(_ context.Context, address Address) (Point, error) {
+	if address.IsZero() {
+		return Point{}, ErrEmptyAddress
+	}
+	p, ok := f.points[address.key()]
+	if !ok {
+		return Point{}, fmt.Errorf("%w: %s", ErrNotFound, address)
+	}
+	return p, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package geocode looks up the coordinates of postal addresses. Providers
+// are pluggable, results can be cached in a file.
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned for addresses a provider does not know.
+	ErrNotFound = errors.New("address not found")
+	// ErrEmptyAddress is returned for addresses without any field.
+	ErrEmptyAddress = errors.New("empty address")
+)
+
+// MaxMismatch is the distance in meters between the coordinates of a place
+// and those of its address, beyond which they are taken to disagree. It is
+// generous, as the coordinates of venues and airports often mark an
+// entrance or terminal rather than the address.
+const MaxMismatch = 2000
+
+// earthRadius is the mean radius of the earth in meters.
+const earthRadius = 6371008.8
+
+// Address is a postal address.
+type Address struct {
+	Street       string
+	StreetNumber string
+	ZipCode      string
+	City         string
+	Country      string
+}
+
+// IsZero reports whether all fields of the address are blank.
+func (a Address) IsZero() bool {
+	return a.String() == ""
+}
+
+// String returns the address on one line, e.g.
+// "Unter den Linden 77, 10117 Berlin, Germany".
+func (a Address) String() string {
+	var parts []string
+	for _, part := range []string{
+		strings.TrimSpace(strings.TrimSpace(a.Street) + " " + strings.TrimSpace(a.StreetNumber)),
+		strings.TrimSpace(strings.TrimSpace(a.ZipCode) + " " + strings.TrimSpace(a.City)),
+		strings.TrimSpace(a.Country),
+	} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// key returns the address in a form that does not depend on case and
+// spacing.
+func (a Address) key() string {
+	return strings.Join(strings.Fields(strings.ToLower(a.String())), " ")
+}
+
+// Point is a place on earth in degrees.
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Distance returns the great-circle distance between two points in meters.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+type Provider interface {
+	// Geocode returns the coordinates of an address, or ErrNotFound.
+	Geocode(ctx context.Context, address Address) (Point, error)
+}
+
+// Open returns the provider described by a connection string:
+//
+//	nominatim                        the public OpenStreetMap Nominatim
+//	nominatim+https://geo.example    a self-hosted Nominatim
+//	fixture://testdata/geocode.json  a file mapping addresses to points
+//
+// An empty string returns nil, which disables geocoding.
+func Open(connection string) (Provider, error) {
+	if connection == "" {
+		return nil, nil
+	}
+	if connection == "nominatim" {
+		return NewNominatim(NominatimConfig{})
+	}
+	if base, ok := strings.CutPrefix(connection, "nominatim+"); ok {
+		return NewNominatim(NominatimConfig{BaseURL: base})
+	}
+	u, err := url.Parse(connection)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "fixture":
+		return LoadFixture(u.Host + u.Path)
+	default:
+		return nil, fmt.Errorf("unknown geocoding provider %q", connection)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package geocode
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	brandenburgGate = Address{Street: "Pariser Platz", ZipCode: "10117", City: "Berlin", Country: "Germany"}
+	museumIsland    = Address{Street: "Bodestraße", StreetNumber: "1", ZipCode: "10178", City: "Berlin", Country: "Germany"}
+)
+
+// fixture is the offline provider of the tests.
+func fixture() *Fixture {
+	return NewFixture(map[Address]Point{
+		brandenburgGate: {Latitude: 52.5163, Longitude: 13.3777},
+		museumIsland:    {Latitude: 52.5211, Longitude: 13.3969},
+	})
+}
+
+func TestAddress_String(t *testing.T) {
+	tt := []struct {
+		address Address
+		want    string
+	}{
+		{address: museumIsland, want: "Bodestraße 1, 10178 Berlin, Germany"},
+		{address: Address{City: " Berlin ", Country: "Germany"}, want: "Berlin, Germany"},
+		{address: Address{StreetNumber: "1", ZipCode: "10178"}, want: "1, 10178"},
+		{address: Address{Street: "  "}, want: ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := tc.address.String(); got != tc.want {
+				t.Errorf("String() = %q, want %q", got, tc.want)
+			}
+			if got := tc.address.IsZero(); got != (tc.want == "") {
+				t.Errorf("IsZero() = %v", got)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tt := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{name: "same point", a: Point{52.5163, 13.3777}, b: Point{52.5163, 13.3777}, want: 0},
+		{name: "berlin to paris", a: Point{52.5200, 13.4050}, b: Point{48.8566, 2.3522}, want: 878_000},
+		{name: "one degree at the equator", a: Point{0, 0}, b: Point{0, 1}, want: 111_195},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			// NOTE: the great-circle distance is only exact to about 0.5%.
+			if got := Distance(tc.a, tc.b); math.Abs(got-tc.want) > tc.want*0.005+1e-6 {
+				t.Errorf("Distance() = %.0f, want %.0f", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFixture(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "geocode.json")
+	data := `{"pariser platz,  10117 Berlin, Germany": {"latitude": 52.5163, "longitude": 13.3777}}`
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Open("fixture://" + name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, f := range map[string]Provider{"new": fixture(), "loaded": loaded} {
+		t.Run(name, func(t *testing.T) {
+			p, err := f.Geocode(context.Background(), Address{Street: "Pariser  Platz", ZipCode: "10117", City: "BERLIN", Country: "Germany"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p != (Point{Latitude: 52.5163, Longitude: 13.3777}) {
+				t.Errorf("got %+v", p)
+			}
+			if _, err := f.Geocode(context.Background(), Address{City: "Atlantis"}); !errors.Is(err, ErrNotFound) {
+				t.Errorf("got error %v, want %v", err, ErrNotFound)
+			}
+			if _, err := f.Geocode(context.Background(), Address{}); !errors.Is(err, ErrEmptyAddress) {
+				t.Errorf("got error %v, want %v", err, ErrEmptyAddress)
+			}
+		})
+	}
+}
+
+// countingProvider counts the lookups of a provider.
+type countingProvider struct {
+	Provider
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Geocode(ctx context.Context, address Address) (Point, error) {
+	p.calls++
+	if p.err != nil {
+		return Point{}, p.err
+	}
+	return p.Provider.Geocode(ctx, address)
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "cache.json")
+	provider := &countingProvider{Provider: fixture()}
+	cache, err := NewCache(provider, file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Geocode(ctx, museumIsland); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := cache.Geocode(ctx, Address{City: "Atlantis"}); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got error %v, want %v", err, ErrNotFound)
+		}
+	}
+	if provider.calls != 2 {
+		t.Errorf("provider was called %d times, want 2", provider.calls)
+	}
+
+	// NOTE: addresses that were not found are looked up again after a day.
+	now = now.Add(notFoundTTL)
+	if _, err := cache.Geocode(ctx, Address{City: "Atlantis"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrNotFound)
+	}
+	if provider.calls != 3 {
+		t.Errorf("provider was called %d times, want 3", provider.calls)
+	}
+
+	// NOTE: other errors are not cached.
+	provider.err = errors.New("offline")
+	if _, err := cache.Geocode(ctx, brandenburgGate); err == nil {
+		t.Fatal("expected an error")
+	}
+	provider.err = nil
+	if _, err := cache.Geocode(ctx, brandenburgGate); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := NewCache(&countingProvider{err: errors.New("must not be called")}, file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p, err := reopened.Geocode(ctx, museumIsland)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p != (Point{Latitude: 52.5211, Longitude: 13.3969}) {
+		t.Errorf("got %+v", p)
+	}
+}
+
+func TestNominatim(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.Header.Get("User-Agent") != "party-test" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		q := r.URL.Query()
+		switch {
+		case q.Get("city") == "Error":
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		case q.Get("street") == "1 Bodestraße" && q.Get("postalcode") == "10178" && q.Get("country") == "Germany" && q.Get("format") == "jsonv2":
+			_, _ = w.Write([]byte(`[{"lat": "52.5211", "lon": "13.3969", "display_name": "Altes Museum"}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+
+	n, err := NewNominatim(NominatimConfig{BaseURL: srv.URL + "/", UserAgent: "party-test", Interval: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tt := []struct {
+		name    string
+		address Address
+		want    Point
+		wantErr error
+		// wantServerErr expects an error other than ErrNotFound.
+		wantServerErr bool
+	}{
+		{name: "found", address: museumIsland, want: Point{Latitude: 52.5211, Longitude: 13.3969}},
+		{name: "not found", address: Address{City: "Atlantis"}, wantErr: ErrNotFound},
+		{name: "empty", address: Address{}, wantErr: ErrEmptyAddress},
+		{name: "server error", address: Address{City: "Error"}, wantServerErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := n.Geocode(context.Background(), tc.address)
+			if tc.wantServerErr {
+				if err == nil || errors.Is(err, ErrNotFound) {
+					t.Fatalf("got error %v, want a server error", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if p, err := Open(""); p != nil || err != nil {
+		t.Errorf("Open(\"\") = %v, %v, want nil, nil", p, err)
+	}
+	if _, err := Open("nominatim+https://geo.example"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := Open("google"); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type NominatimConfig struct {
+	// BaseURL defaults to the public instance of OpenStreetMap.
+	BaseURL string
+	// UserAgent identifies the application, as required by the usage policy
+	// of the public instance.
+	UserAgent string
+	// Interval is the minimum time between two requests. It defaults to one
+	// second, the limit of the public instance.
+	Interval time.Duration
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Nominatim looks up addresses with the search API of Nominatim, the
+// geocoder of OpenStreetMap.
+type Nominatim struct {
+	config NominatimConfig
+
+	// mu spaces the requests by the configured interval.
+	mu   sync.Mutex
+	last time.Time
+}
+
+func NewNominatim(config NominatimConfig) (*Nominatim, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://nominatim.openstreetmap.org"
+	}
+	if _, err := url.Parse(config.BaseURL); err != nil {
+		return nil, fmt.Errorf("invalid Nominatim URL: %w", err)
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	if config.UserAgent == "" {
+		config.UserAgent = "quixsi-party-invite"
+	}
+	if config.Interval == 0 {
+		config.Interval = time.Second
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return &Nominatim{config: config}, nil
+}
+
+// wait blocks until the next request may be sent.
+func (n *Nominatim) wait(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if d := time.Until(n.last.Add(n.config.Interval)); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	n.last = time.Now()
+	return nil
+}
+
+func (n *Nominatim) Geocode(ctx context.Context, address Address) (Point, error) {
+	if address.IsZero() {
+		return Point{}, ErrEmptyAddress
+	}
+	query := url.Values{"format": {"jsonv2"}, "limit": {"1"}}
+	for name, value := range map[string]string{
+		"street":     strings.TrimSpace(strings.TrimSpace(address.StreetNumber) + " " + strings.TrimSpace(address.Street)),
+		"postalcode": strings.TrimSpace(address.ZipCode),
+		"city":       strings.TrimSpace(address.City),
+		"country":    strings.TrimSpace(address.Country),
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.config.BaseURL+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return Point{}, err
+	}
+	req.Header.Set("User-Agent", n.config.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	if err := n.wait(ctx); err != nil {
+		return Point{}, err
+	}
+	resp, err := n.config.Client.Do(req)
+	if err != nil {
+		return Point{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Point{}, fmt.Errorf("nominatim: unexpected status %s", resp.Status)
+	}
+
+	// NOTE: Nominatim returns the coordinates as strings.
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return Point{}, fmt.Errorf("nominatim: %w", err)
+	}
+	if len(results) == 0 {
+		return Point{}, fmt.Errorf("%w: %s", ErrNotFound, address)
+	}
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("nominatim: invalid latitude: %w", err)
+	}
+	lon, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("nominatim: invalid longitude: %w", err)
+	}
+	return Point{Latitude: lat, Longitude: lon}, nil
+}
@@ -0,0 +1,312 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package ical writes calendars in the iCalendar format (RFC 5545).
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateTimeLayout = "20060102T150405"
+	// maxLineLength is the maximum length of a content line in octets,
+	// excluding the line break.
+	maxLineLength = 75
+)
+
+// Calendar is a collection of events.
+type Calendar struct {
+	// ProdID identifies the product that created the calendar.
+	ProdID string
+	// Name is shown by calendar applications subscribing to the calendar.
+	Name string
+	// RefreshInterval suggests how often subscribers should update the
+	// calendar. Zero omits the suggestion.
+	RefreshInterval time.Duration
+	// Stamp is the time the calendar was created.
+	Stamp  time.Time
+	Events []Event
+}
+
+// Event is a single event of a calendar. Times in a named time zone refer to
+// a time zone definition of the calendar, all other times are written in UTC.
+type Event struct {
+	UID   string
+	Start time.Time
+	// End is optional. Without an end, the event takes no time.
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	// Latitude and Longitude are only written if either is not zero.
+	Latitude  float64
+	Longitude float64
+}
+
+// Encode writes the calendar to w.
+func (c *Calendar) Encode(w io.Writer) error {
+	enc := &encoder{w: bufio.NewWriter(w)}
+	enc.line("BEGIN", "VCALENDAR")
+	enc.line("VERSION", "2.0")
+	enc.line("PRODID", c.ProdID)
+	enc.line("CALSCALE", "GREGORIAN")
+	enc.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		enc.text("NAME", c.Name)
+		enc.text("X-WR-CALNAME", c.Name)
+	}
+	if c.RefreshInterval > 0 {
+		enc.line("REFRESH-INTERVAL;VALUE=DURATION", duration(c.RefreshInterval))
+		enc.line("X-PUBLISHED-TTL", duration(c.RefreshInterval))
+	}
+	for _, tz := range c.timezones() {
+		tz.encode(enc)
+	}
+	for _, e := range c.Events {
+		e.encode(enc, c.Stamp)
+	}
+	enc.line("END", "VCALENDAR")
+	if enc.err != nil {
+		return enc.err
+	}
+	return enc.w.Flush()
+}
+
+func (e *Event) encode(enc *encoder, stamp time.Time) {
+	enc.line("BEGIN", "VEVENT")
+	enc.line("UID", e.UID)
+	enc.line("DTSTAMP", stamp.UTC().Format(dateTimeLayout)+"Z")
+	enc.time("DTSTART", e.Start)
+	if !e.End.IsZero() {
+		enc.time("DTEND", e.End)
+	}
+	enc.text("SUMMARY", e.Summary)
+	if e.Description != "" {
+		enc.text("DESCRIPTION", e.Description)
+	}
+	if e.Location != "" {
+		enc.text("LOCATION", e.Location)
+	}
+	if e.Latitude != 0 || e.Longitude != 0 {
+		enc.line("GEO", fmt.Sprintf("%.6f;%.6f", e.Latitude, e.Longitude))
+	}
+	if e.URL != "" {
+		enc.line("URL", e.URL)
+	}
+	enc.line("END", "VEVENT")
+}
+
+// duration formats d as a duration value, e.g. PT1H.
+func duration(d time.Duration) string {
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := int(d.Hours()); h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+	}
+	if m := int(d.Minutes()) % 60; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	if s := int(d.Seconds()) % 60; s > 0 || b.Len() == 2 {
+		fmt.Fprintf(&b, "%dS", s)
+	}
+	return b.String()
+}
+
+// timezones returns the definitions of all time zones the events refer to.
+// Each definition covers the years of the events referring to it.
+func (c *Calendar) timezones() []*timezone {
+	zones := make(map[string]*timezone)
+	add := func(t time.Time) {
+		if t.IsZero() || !hasTZID(t.Location()) {
+			return
+		}
+		tz, ok := zones[t.Location().String()]
+		if !ok {
+			tz = &timezone{loc: t.Location(), from: t.Year(), to: t.Year()}
+			zones[t.Location().String()] = tz
+		}
+		tz.from = min(tz.from, t.Year())
+		tz.to = max(tz.to, t.Year())
+	}
+	for _, e := range c.Events {
+		add(e.Start)
+		add(e.End)
+	}
+
+	res := make([]*timezone, 0, len(zones))
+	for _, tz := range zones {
+		res = append(res, tz)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].loc.String() < res[j].loc.String()
+	})
+	return res
+}
+
+// hasTZID reports whether times in loc are written with a time zone
+// reference. Fixed offsets without a name and the local time zone of the
+// server have no meaningful identifier.
+func hasTZID(loc *time.Location) bool {
+	switch loc.String() {
+	case "", "UTC", "Local":
+		return false
+	}
+	return true
+}
+
+// timezone is the definition of a time zone for the years from and to.
+type timezone struct {
+	loc      *time.Location
+	from, to int
+}
+
+// transition is a change of the UTC offset of a time zone.
+type transition struct {
+	at         time.Time
+	offsetFrom int
+}
+
+func (tz *timezone) encode(enc *encoder) {
+	enc.line("BEGIN", "VTIMEZONE")
+	enc.line("TZID", tz.loc.String())
+
+	transitions := tz.transitions()
+	if len(transitions) == 0 {
+		// NOTE: the time zone never changes its offset, so a single
+		// observance covers all times.
+		start := time.Date(1970, time.January, 1, 0, 0, 0, 0, tz.loc)
+		_, offset := start.Zone()
+		tz.observance(enc, transition{at: start, offsetFrom: offset})
+	}
+	for _, t := range transitions {
+		tz.observance(enc, t)
+	}
+	enc.line("END", "VTIMEZONE")
+}
+
+func (tz *timezone) observance(enc *encoder, t transition) {
+	kind := "STANDARD"
+	if t.at.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	name, offset := t.at.Zone()
+
+	enc.line("BEGIN", kind)
+	// NOTE: the onset is given in the local time before the transition.
+	enc.line("DTSTART", t.at.In(time.FixedZone("", t.offsetFrom)).Format(dateTimeLayout))
+	enc.line("TZOFFSETFROM", utcOffset(t.offsetFrom))
+	enc.line("TZOFFSETTO", utcOffset(offset))
+	enc.text("TZNAME", name)
+	enc.line("END", kind)
+}
+
+// transitions returns the offset changes of the time zone within its years,
+// including the last change before them.
+func (tz *timezone) transitions() []transition {
+	var res []transition
+	start := time.Date(tz.from-1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(tz.to+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for t := start; t.Before(end); t = t.Add(24 * time.Hour) {
+		_, before := t.In(tz.loc).Zone()
+		_, after := t.Add(24 * time.Hour).In(tz.loc).Zone()
+		if before == after {
+			continue
+		}
+		// NOTE: binary search for the first second with the new offset.
+		lo, hi := t, t.Add(24*time.Hour)
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if _, offset := mid.In(tz.loc).Zone(); offset == before {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		res = append(res, transition{at: hi.In(tz.loc), offsetFrom: before})
+	}
+
+	// NOTE: drop the changes of the year before, except for the last one,
+	// which is in effect at the beginning of the first year.
+	first := time.Date(tz.from, time.January, 1, 0, 0, 0, 0, tz.loc)
+	for len(res) > 1 && res[1].at.Before(first) {
+		res = res[1:]
+	}
+	return res
+}
+
+// utcOffset formats an offset in seconds east of UTC, e.g. +0100.
+func utcOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	res := fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset/60%60)
+	if offset%60 != 0 {
+		res += fmt.Sprintf("%02d", offset%60)
+	}
+	return res
+}
+
+// encoder writes content lines, folding them at maxLineLength octets.
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (enc *encoder) time(name string, t time.Time) {
+	if !hasTZID(t.Location()) {
+		enc.line(name, t.UTC().Format(dateTimeLayout)+"Z")
+		return
+	}
+	enc.line(name+";TZID="+t.Location().String(), t.Format(dateTimeLayout))
+}
+
+func (enc *encoder) text(name, value string) {
+	enc.line(name, escapeText(value))
+}
+
+func (enc *encoder) line(name, value string) {
+	if enc.err != nil {
+		return
+	}
+	line := name + ":" + value
+	// NOTE: the leading space of a continuation line counts towards its
+	// length.
+	limit := maxLineLength
+	for len(line) > limit {
+		// NOTE: never split a multi-byte character.
+		n := limit
+		for n > 0 && !utf8.RuneStart(line[n]) {
+			n--
+		}
+		if _, enc.err = enc.w.WriteString(line[:n] + "\r\n "); enc.err != nil {
+			return
+		}
+		line = line[n:]
+		limit = maxLineLength - 1
+	}
+	_, enc.err = enc.w.WriteString(line + "\r\n")
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package ical
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestCalendar_Encode(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamp := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	tt := []struct {
+		name     string
+		calendar Calendar
+	}{
+		{
+			name: "utc",
+			calendar: Calendar{
+				ProdID: "-//quixsi//core//EN",
+				Stamp:  stamp,
+				Events: []Event{{
+					UID:     "event@example.com",
+					Start:   time.Date(2024, time.May, 1, 16, 0, 0, 0, time.UTC),
+					Summary: "Party",
+				}},
+			},
+		},
+		{
+			name: "timezones",
+			calendar: Calendar{
+				ProdID: "-//quixsi//core//EN",
+				Stamp:  stamp,
+				Events: []Event{
+					{
+						UID:         "event@example.com",
+						Start:       time.Date(2024, time.May, 1, 18, 0, 0, 0, berlin),
+						Summary:     "Party von Jamcan Pimpleworthy",
+						Description: "Bring your dancing shoes; the party lasts long.\nSee you there, friends!",
+						Location:    `Demo Location, Musterstraße 1, 12345 Berlin, Germany`,
+						URL:         "https://example.com/ba20785f-8c7b-442e-935a-1cb58c41b92a",
+						Latitude:    52.520008,
+						Longitude:   13.404954,
+					},
+					{
+						UID:     "brunch@example.com",
+						Start:   time.Date(2025, time.January, 2, 10, 0, 0, 0, berlin),
+						End:     time.Date(2025, time.January, 2, 13, 30, 0, 0, berlin),
+						Summary: "Brunch",
+					},
+					{
+						UID:     "flight@example.com",
+						Start:   time.Date(2024, time.May, 3, 9, 0, 0, 0, kolkata),
+						Summary: "Honeymoon",
+					},
+				},
+			},
+		},
+		{
+			name: "feed",
+			calendar: Calendar{
+				ProdID:          "-//quixsi//core//EN",
+				Name:            "Party of Jamcan Pimpleworthy (admin)",
+				RefreshInterval: time.Hour,
+				Stamp:           stamp,
+				Events: []Event{{
+					UID:         "event@example.com",
+					Start:       time.Date(2024, time.May, 1, 16, 0, 0, 0, time.FixedZone("", 2*60*60)),
+					Summary:     "Party",
+					Description: strings.Repeat("Überraschung! ", 12),
+				}},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tc.calendar.Encode(&buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, line := range strings.SplitAfter(buf.String(), "\r\n") {
+				if len(line) > maxLineLength+2 {
+					t.Errorf("line is longer than %d octets: %q", maxLineLength, line)
+				}
+			}
+
+			golden := filepath.Join("testdata", tc.name+".ics")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tt := []struct {
+		in   string
+		want string
+	}{
+		{in: "Party", want: "Party"},
+		{in: `a\b`, want: `a\\b`},
+		{in: "a;b,c", want: `a\;b\,c`},
+		{in: "a\r\nb\nc", want: `a\nb\nc`},
+	}
+
+	for _, tc := range tt {
+		if got := escapeText(tc.in); got != tc.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package locale formats dates, numbers and lists in the language of a
+// translation.
+package locale
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale contains the names and layouts used to format dates in a language.
+// The layouts use the reference time of the time package with English names,
+// which Format replaces by the names of the locale.
+type Locale struct {
+	Months      [12]string
+	ShortMonths [12]string
+	Days        [7]string
+	ShortDays   [7]string
+	// DateLayout formats a date including the weekday and the year.
+	DateLayout string
+	// DayMonthLayout formats the day and month of a date.
+	DayMonthLayout string
+	// TimeLayout formats the time of day.
+	TimeLayout string
+
+	// And joins the last two items of a list.
+	And string
+	// AttachAnd writes And together with the last item, e.g. Arabic "و".
+	// Other conjunctions passed to JoinList are always followed by a space.
+	AttachAnd bool
+	// ListSeparator separates the other items of a list. Empty means ", ".
+	ListSeparator string
+	// SerialComma puts a comma before And in lists of three or more items.
+	SerialComma bool
+	// DecimalSeparator and GroupSeparator are used to format numbers.
+	DecimalSeparator string
+	GroupSeparator   string
+	// CurrencyPattern places the currency symbol ¤ relative to the amount #.
+	CurrencyPattern string
+
+	// one reports whether a count takes the singular form. Nil means only 1
+	// does.
+	one func(n int) bool
+}
+
+// DateTimeLayout formats a date and the time of day including the time zone.
+func (l *Locale) DateTimeLayout() string {
+	return l.DateLayout + " - " + l.TimeLayout + " MST"
+}
+
+// English is used for languages without a locale of their own.
+var English = &Locale{
+	Months:         [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	ShortMonths:    [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	Days:           [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	ShortDays:      [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	DateLayout:     "Monday, January 2, 2006",
+	DayMonthLayout: "January 2",
+	TimeLayout:     "3:04 PM",
+
+	And:              "and",
+	SerialComma:      true,
+	DecimalSeparator: ".",
+	GroupSeparator:   ",",
+	CurrencyPattern:  "¤#",
+}
+
+var locales = map[string]*Locale{
+	"en": English,
+	"de": {
+		Months:         [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths:    [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		Days:           [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		ShortDays:      [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		DateLayout:     "Monday, 2. January 2006",
+		DayMonthLayout: "2. January",
+		TimeLayout:     "15:04",
+
+		And:              "und",
+		DecimalSeparator: ",",
+		GroupSeparator:   ".",
+		CurrencyPattern:  "#\u00a0¤",
+	},
+	"fr": {
+		Months:         [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		ShortMonths:    [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Days:           [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		ShortDays:      [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		DateLayout:     "Monday 2 January 2006",
+		DayMonthLayout: "2 January",
+		TimeLayout:     "15:04",
+
+		And:              "et",
+		DecimalSeparator: ",",
+		GroupSeparator:   "\u202f",
+		CurrencyPattern:  "#\u00a0¤",
+		one:              func(n int) bool { return n == 0 || n == 1 },
+	},
+	"es": {
+		Months:         [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		ShortMonths:    [12]string{"ene.", "feb.", "mar.", "abr.", "may.", "jun.", "jul.", "ago.", "sept.", "oct.", "nov.", "dic."},
+		Days:           [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		ShortDays:      [7]string{"dom.", "lun.", "mar.", "mié.", "jue.", "vie.", "sáb."},
+		DateLayout:     "Monday, 2 de January de 2006",
+		DayMonthLayout: "2 de January",
+		TimeLayout:     "15:04",
+
+		And:              "y",
+		DecimalSeparator: ",",
+		GroupSeparator:   ".",
+		CurrencyPattern:  "#\u00a0¤",
+	},
+	"it": {
+		Months:         [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		ShortMonths:    [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		Days:           [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		ShortDays:      [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		DateLayout:     "Monday 2 January 2006",
+		DayMonthLayout: "2 January",
+		TimeLayout:     "15:04",
+
+		And:              "e",
+		DecimalSeparator: ",",
+		GroupSeparator:   ".",
+		CurrencyPattern:  "#\u00a0¤",
+	},
+	"nl": {
+		Months:         [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		ShortMonths:    [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		Days:           [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		ShortDays:      [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		DateLayout:     "Monday 2 January 2006",
+		DayMonthLayout: "2 January",
+		TimeLayout:     "15:04",
+
+		And:              "en",
+		DecimalSeparator: ",",
+		GroupSeparator:   ".",
+		CurrencyPattern:  "¤\u00a0#",
+	},
+	"ar": {
+		Months:         [12]string{"يناير", "فبراير", "مارس", "أبريل", "مايو", "يونيو", "يوليو", "أغسطس", "سبتمبر", "أكتوبر", "نوفمبر", "ديسمبر"},
+		ShortMonths:    [12]string{"يناير", "فبراير", "مارس", "أبريل", "مايو", "يونيو", "يوليو", "أغسطس", "سبتمبر", "أكتوبر", "نوفمبر", "ديسمبر"},
+		Days:           [7]string{"الأحد", "الاثنين", "الثلاثاء", "الأربعاء", "الخميس", "الجمعة", "السبت"},
+		ShortDays:      [7]string{"الأحد", "الاثنين", "الثلاثاء", "الأربعاء", "الخميس", "الجمعة", "السبت"},
+		DateLayout:     "Monday، 2 January 2006",
+		DayMonthLayout: "2 January",
+		TimeLayout:     "15:04",
+
+		And:              "و",
+		AttachAnd:        true,
+		ListSeparator:    "، ",
+		DecimalSeparator: ".",
+		GroupSeparator:   ",",
+		CurrencyPattern:  "#\u00a0¤",
+	},
+	"he": {
+		Months:         [12]string{"ינואר", "פברואר", "מרץ", "אפריל", "מאי", "יוני", "יולי", "אוגוסט", "ספטמבר", "אוקטובר", "נובמבר", "דצמבר"},
+		ShortMonths:    [12]string{"ינו׳", "פבר׳", "מרץ", "אפר׳", "מאי", "יוני", "יולי", "אוג׳", "ספט׳", "אוק׳", "נוב׳", "דצמ׳"},
+		Days:           [7]string{"יום ראשון", "יום שני", "יום שלישי", "יום רביעי", "יום חמישי", "יום שישי", "יום שבת"},
+		ShortDays:      [7]string{"יום א׳", "יום ב׳", "יום ג׳", "יום ד׳", "יום ה׳", "יום ו׳", "שבת"},
+		DateLayout:     "Monday, 2 בJanuary 2006",
+		DayMonthLayout: "2 בJanuary",
+		TimeLayout:     "15:04",
+
+		And:              "ו",
+		AttachAnd:        true,
+		DecimalSeparator: ".",
+		GroupSeparator:   ",",
+		CurrencyPattern:  "#\u00a0¤",
+	},
+}
+
+// rtlLanguages are the languages written from right to left.
+var rtlLanguages = map[string]bool{
+	"ar": true, "arc": true, "ckb": true, "dv": true, "fa": true, "he": true,
+	"iw": true, "ks": true, "ps": true, "sd": true, "ug": true, "ur": true,
+	"yi": true,
+}
+
+// rtlScripts are the scripts written from right to left, e.g. "Arab" in
+// "pa-Arab".
+var rtlScripts = map[string]bool{
+	"arab": true, "hebr": true, "nkoo": true, "syrc": true, "thaa": true,
+}
+
+// Direction returns the direction in which a language is written, "rtl" or
+// "ltr". A script in the tag takes precedence over the language, e.g.
+// "az-Arab" is written from right to left and "ku-Latn" from left to right.
+func Direction(lang string) string {
+	parts := strings.Split(strings.ToLower(strings.ReplaceAll(lang, "_", "-")), "-")
+	for _, p := range parts[1:] {
+		if len(p) == 4 {
+			if rtlScripts[p] {
+				return "rtl"
+			}
+			return "ltr"
+		}
+	}
+	if rtlLanguages[parts[0]] {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// For returns the locale of a language, e.g. "de" or "de-AT". Unknown
+// languages use English.
+func For(lang string) *Locale {
+	lang = strings.ToLower(lang)
+	if l, ok := locales[lang]; ok {
+		return l
+	}
+	if base, _, ok := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-"); ok {
+		if l, ok := locales[base]; ok {
+			return l
+		}
+	}
+	return English
+}
+
+// nameTokens are the elements of a layout that are replaced by the names of
+// the locale, longest first.
+var nameTokens = []string{"January", "Monday", "Jan", "Mon"}
+
+// Format returns t formatted according to layout like time.Time.Format, but
+// with the month and weekday names of the locale.
+func (l *Locale) Format(t time.Time, layout string) string {
+	var b strings.Builder
+	for layout != "" {
+		token, i := nextNameToken(layout)
+		b.WriteString(t.Format(layout[:i]))
+		if token == "" {
+			break
+		}
+		switch token {
+		case "January":
+			b.WriteString(l.Months[t.Month()-1])
+		case "Jan":
+			b.WriteString(l.ShortMonths[t.Month()-1])
+		case "Monday":
+			b.WriteString(l.Days[t.Weekday()])
+		case "Mon":
+			b.WriteString(l.ShortDays[t.Weekday()])
+		}
+		layout = layout[i+len(token):]
+	}
+	return b.String()
+}
+
+// nextNameToken returns the first name token of layout and its index. If
+// there is none, it returns the length of layout.
+func nextNameToken(layout string) (string, int) {
+	for i := range layout {
+		for _, token := range nameTokens {
+			if strings.HasPrefix(layout[i:], token) {
+				return token, i
+			}
+		}
+	}
+	return "", len(layout)
+}
+
+// JoinList joins the items of a list, e.g. "Anna, Ben and Carl". If and is
+// empty, the conjunction of the locale is used.
+func (l *Locale) JoinList(items []string, and string) string {
+	if and == "" {
+		and = l.And
+	}
+	if !l.AttachAnd || and != l.And {
+		and += " "
+	}
+	separator := l.ListSeparator
+	if separator == "" {
+		separator = ", "
+	}
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	case 2:
+		return items[0] + " " + and + items[1]
+	}
+	last := len(items) - 1
+	sep := " "
+	if l.SerialComma {
+		sep = separator
+	}
+	return strings.Join(items[:last], separator) + sep + and + items[last]
+}
+
+// Plural returns one if a count of n takes the singular form in the language
+// of the locale, and other otherwise.
+func (l *Locale) Plural(n int, one, other string) string {
+	isOne := n == 1
+	if l.one != nil {
+		isOne = l.one(n)
+	}
+	if isOne {
+		return one
+	}
+	return other
+}
+
+// FormatNumber formats a number with the given number of decimals, e.g.
+// "1.234,50" in German.
+func (l *Locale) FormatNumber(f float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(l.GroupSeparator)
+		}
+		b.WriteRune(r)
+	}
+	if fraction != "" {
+		b.WriteString(l.DecimalSeparator)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// currencies are the symbols and decimals of common ISO 4217 currencies.
+// Other currencies are written with their code and two decimals.
+var currencies = map[string]struct {
+	symbol   string
+	decimals int
+}{
+	"EUR": {symbol: "€", decimals: 2},
+	"USD": {symbol: "$", decimals: 2},
+	"GBP": {symbol: "£", decimals: 2},
+	"CHF": {symbol: "CHF", decimals: 2},
+	"JPY": {symbol: "¥", decimals: 0},
+	"VND": {symbol: "₫", decimals: 0},
+}
+
+// FormatCurrency formats an amount of money in the currency with the given
+// ISO 4217 code, e.g. "1.234,50 €" in German.
+func (l *Locale) FormatCurrency(amount float64, code string) string {
+	code = strings.ToUpper(code)
+	symbol, decimals := code, 2
+	if c, ok := currencies[code]; ok {
+		symbol, decimals = c.symbol, c.decimals
+	}
+	number := l.FormatNumber(amount, decimals)
+	res := strings.Replace(l.CurrencyPattern, "#", strings.TrimPrefix(number, "-"), 1)
+	res = strings.Replace(res, "¤", symbol, 1)
+	if strings.HasPrefix(number, "-") {
+		res = "-" + res
+	}
+	return res
+}
@@ -0,0 +1,184 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocale_Format(t *testing.T) {
+	ts := time.Date(2024, time.March, 2, 18, 30, 0, 0, time.FixedZone("CET", 60*60))
+
+	tt := []struct {
+		name   string
+		lang   string
+		layout string
+		want   string
+	}{
+		{
+			name:   "english",
+			lang:   "en",
+			layout: English.DateTimeLayout(),
+			want:   "Saturday, March 2, 2024 - 6:30 PM CET",
+		},
+		{
+			name:   "german",
+			lang:   "de",
+			layout: For("de").DateTimeLayout(),
+			want:   "Samstag, 2. März 2024 - 18:30 CET",
+		},
+		{
+			name:   "short names",
+			lang:   "fr",
+			layout: "Mon 02 Jan 06",
+			want:   "sam. 02 mars 24",
+		},
+		{
+			name:   "region",
+			lang:   "es-MX",
+			layout: For("es").DayMonthLayout,
+			want:   "2 de marzo",
+		},
+		{
+			name:   "hebrew",
+			lang:   "he",
+			layout: For("he").DateLayout,
+			want:   "יום שבת, 2 במרץ 2024",
+		},
+		{
+			name:   "unknown language",
+			lang:   "xx",
+			layout: "2. January",
+			want:   "2. March",
+		},
+		{
+			name:   "no names",
+			lang:   "de",
+			layout: "2006-01-02T15:04",
+			want:   "2024-03-02T18:30",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := For(tc.lang).Format(ts, tc.layout); got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLocale_JoinList(t *testing.T) {
+	tt := []struct {
+		lang  string
+		items []string
+		and   string
+		want  string
+	}{
+		{lang: "en", items: nil, want: ""},
+		{lang: "en", items: []string{"Anna"}, want: "Anna"},
+		{lang: "en", items: []string{"Anna", "Ben"}, want: "Anna and Ben"},
+		{lang: "en", items: []string{"Anna", "Ben", "Carl"}, want: "Anna, Ben, and Carl"},
+		{lang: "de", items: []string{"Anna", "Ben", "Carl"}, want: "Anna, Ben und Carl"},
+		{lang: "de", items: []string{"Anna", "Ben"}, and: "&", want: "Anna & Ben"},
+		{lang: "xx", items: []string{"Anna", "Ben"}, want: "Anna and Ben"},
+		{lang: "ar-EG", items: []string{"سارة", "علي", "منى"}, want: "سارة، علي ومنى"},
+		{lang: "he", items: []string{"דנה", "יוסי"}, want: "דנה ויוסי"},
+		{lang: "ar", items: []string{"Anna", "Ben"}, and: "and", want: "Anna and Ben"},
+	}
+
+	for _, tc := range tt {
+		if got := For(tc.lang).JoinList(tc.items, tc.and); got != tc.want {
+			t.Errorf("%s: JoinList(%q, %q) = %q, want %q", tc.lang, tc.items, tc.and, got, tc.want)
+		}
+	}
+}
+
+func TestDirection(t *testing.T) {
+	tt := []struct {
+		lang string
+		want string
+	}{
+		{lang: "en", want: "ltr"},
+		{lang: "de-AT", want: "ltr"},
+		{lang: "ar", want: "rtl"},
+		{lang: "he-IL", want: "rtl"},
+		{lang: "fa_IR", want: "rtl"},
+		{lang: "pa-Arab-PK", want: "rtl"},
+		{lang: "ku-Latn", want: "ltr"},
+		{lang: "", want: "ltr"},
+	}
+
+	for _, tc := range tt {
+		if got := Direction(tc.lang); got != tc.want {
+			t.Errorf("Direction(%q) = %q, want %q", tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestLocale_Plural(t *testing.T) {
+	tt := []struct {
+		lang string
+		n    int
+		want string
+	}{
+		{lang: "en", n: 0, want: "other"},
+		{lang: "en", n: 1, want: "one"},
+		{lang: "en", n: 2, want: "other"},
+		{lang: "fr", n: 0, want: "one"},
+		{lang: "fr", n: 1, want: "one"},
+		{lang: "fr", n: 2, want: "other"},
+	}
+
+	for _, tc := range tt {
+		if got := For(tc.lang).Plural(tc.n, "one", "other"); got != tc.want {
+			t.Errorf("%s: Plural(%d) = %q, want %q", tc.lang, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestLocale_FormatNumber(t *testing.T) {
+	tt := []struct {
+		lang     string
+		f        float64
+		decimals int
+		want     string
+	}{
+		{lang: "en", f: 1234567.891, decimals: 2, want: "1,234,567.89"},
+		{lang: "de", f: 1234.5, decimals: 2, want: "1.234,50"},
+		{lang: "de", f: 999, decimals: 0, want: "999"},
+		{lang: "fr", f: 1234.5, decimals: 1, want: "1\u202f234,5"},
+		{lang: "en", f: -1234, decimals: 0, want: "-1,234"},
+		{lang: "en", f: -0.001, decimals: 2, want: "0.00"},
+	}
+
+	for _, tc := range tt {
+		if got := For(tc.lang).FormatNumber(tc.f, tc.decimals); got != tc.want {
+			t.Errorf("%s: FormatNumber(%v, %d) = %q, want %q", tc.lang, tc.f, tc.decimals, got, tc.want)
+		}
+	}
+}
+
+func TestLocale_FormatCurrency(t *testing.T) {
+	tt := []struct {
+		lang   string
+		amount float64
+		code   string
+		want   string
+	}{
+		{lang: "en", amount: 1234.5, code: "EUR", want: "€1,234.50"},
+		{lang: "de", amount: 1234.5, code: "EUR", want: "1.234,50\u00a0€"},
+		{lang: "nl", amount: 25, code: "usd", want: "$\u00a025,00"},
+		{lang: "en", amount: 1500, code: "JPY", want: "¥1,500"},
+		{lang: "en", amount: 10, code: "SEK", want: "SEK10.00"},
+		{lang: "de", amount: -5, code: "EUR", want: "-5,00\u00a0€"},
+	}
+
+	for _, tc := range tt {
+		if got := For(tc.lang).FormatCurrency(tc.amount, tc.code); got != tc.want {
+			t.Errorf("%s: FormatCurrency(%v, %q) = %q, want %q", tc.lang, tc.amount, tc.code, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package media scales uploaded images into variants of several widths and
+// stores them in a blob store.
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	// NOTE: GIF and WebP are decoded only, images are encoded as JPEG or PNG.
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"slices"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"github.com/quixsi/core/internal/blob"
+	"github.com/quixsi/core/internal/model"
+)
+
+var (
+	// ErrUnsupported is returned for data that is no PNG, JPEG, GIF or WebP
+	// image.
+	ErrUnsupported = errors.New("no PNG, JPEG, GIF or WebP image")
+	// ErrTooLarge is returned for images with more than maxPixels pixels.
+	ErrTooLarge = errors.New("image has too many pixels")
+)
+
+// Widths are the widths of the variants of an image. Images are not scaled
+// up, so a smaller image gets fewer variants.
+var Widths = []int{480, 960, 1920}
+
+// maxPixels limits the size of decoded images, as a small file may decode
+// into a huge image.
+var maxPixels = 40_000_000
+
+// jpegQuality is the quality of the JPEG variants.
+const jpegQuality = 85
+
+// Variant is an encoded image of one width.
+type Variant struct {
+	Width       int
+	Height      int
+	ContentType string
+	// Ext is the file extension of the encoding, e.g. ".jpg".
+	Ext  string
+	Data []byte
+}
+
+// Resize decodes an image and encodes it in the variant widths. Images with
+// transparency are encoded as PNG, others as JPEG. Metadata like the location
+// of a photo is not copied.
+func Resize(data []byte) ([]Variant, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	opaque := true
+	if o, ok := src.(interface{ Opaque() bool }); ok {
+		opaque = o.Opaque()
+	}
+
+	var variants []Variant
+	for _, width := range variantWidths(src.Bounds().Dx()) {
+		height := max(1, (src.Bounds().Dy()*width+src.Bounds().Dx()/2)/src.Bounds().Dx())
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+		v := Variant{Width: width, Height: height}
+		var buf bytes.Buffer
+		if opaque {
+			v.ContentType, v.Ext = "image/jpeg", ".jpg"
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			v.ContentType, v.Ext = "image/png", ".png"
+			err = png.Encode(&buf, dst)
+		}
+		if err != nil {
+			return nil, err
+		}
+		v.Data = buf.Bytes()
+		variants = append(variants, v)
+	}
+	return variants, nil
+}
+
+// variantWidths returns the widths of the variants of an image of the given
+// width: all smaller Widths and the width itself, limited to the largest of
+// Widths.
+func variantWidths(width int) []int {
+	var res []int
+	for _, w := range Widths {
+		if w < width {
+			res = append(res, w)
+		}
+	}
+	largest := min(width, Widths[len(Widths)-1])
+	if !slices.Contains(res, largest) {
+		res = append(res, largest)
+	}
+	return res
+}
+
+// Store resizes an image and stores its variants as
+// "images/<id>/<width>.<ext>".
+func Store(ctx context.Context, store blob.Store, data []byte) (*model.Image, error) {
+	variants, err := Resize(data)
+	if err != nil {
+		return nil, err
+	}
+	img := &model.Image{ID: uuid.New()}
+	for _, v := range variants {
+		key := fmt.Sprintf("images/%s/%d%s", img.ID, v.Width, v.Ext)
+		if err := store.Put(ctx, key, &blob.Blob{ContentType: v.ContentType, Data: v.Data}); err != nil {
+			// NOTE: the variants stored so far would never be referenced.
+			_ = Delete(ctx, store, img)
+			return nil, fmt.Errorf("store %s: %w", key, err)
+		}
+		img.Variants = append(img.Variants, &model.ImageVariant{Key: key, Width: v.Width, Height: v.Height})
+	}
+	return img, nil
+}
+
+// Delete removes all variants of an image from the store.
+func Delete(ctx context.Context, store blob.Store, img *model.Image) error {
+	var errs []error
+	for _, key := range img.Keys() {
+		if err := store.Delete(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("delete %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
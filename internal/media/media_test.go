@@ -0,0 +1,148 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"testing"
+
+	"github.com/quixsi/core/internal/blob"
+)
+
+func encodePNG(t *testing.T, width, height int, alpha uint8) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: alpha})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestResize(t *testing.T) {
+	tt := []struct {
+		name            string
+		data            []byte
+		wantWidths      []int
+		wantHeights     []int
+		wantContentType string
+		wantErr         error
+	}{
+		{
+			name:            "large photo",
+			data:            encodePNG(t, 2400, 1200, 255),
+			wantWidths:      []int{480, 960, 1920},
+			wantHeights:     []int{240, 480, 960},
+			wantContentType: "image/jpeg",
+		},
+		{
+			name:            "medium photo",
+			data:            encodePNG(t, 1000, 750, 255),
+			wantWidths:      []int{480, 960, 1000},
+			wantHeights:     []int{360, 720, 750},
+			wantContentType: "image/jpeg",
+		},
+		{
+			name:            "small transparent logo",
+			data:            encodePNG(t, 300, 100, 100),
+			wantWidths:      []int{300},
+			wantHeights:     []int{100},
+			wantContentType: "image/png",
+		},
+		{
+			name:            "exact width",
+			data:            encodePNG(t, 480, 1, 255),
+			wantWidths:      []int{480},
+			wantHeights:     []int{1},
+			wantContentType: "image/jpeg",
+		},
+		{name: "svg", data: []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), wantErr: ErrUnsupported},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			variants, err := Resize(tc.data)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			var widths, heights []int
+			for _, v := range variants {
+				widths = append(widths, v.Width)
+				heights = append(heights, v.Height)
+				if v.ContentType != tc.wantContentType {
+					t.Errorf("got content type %q, want %q", v.ContentType, tc.wantContentType)
+				}
+				config, format, err := image.DecodeConfig(bytes.NewReader(v.Data))
+				if err != nil {
+					t.Fatalf("variant can not be decoded: %v", err)
+				}
+				if "image/"+format != v.ContentType || config.Width != v.Width || config.Height != v.Height {
+					t.Errorf("got %s of %dx%d, want %s of %dx%d", format, config.Width, config.Height, v.ContentType, v.Width, v.Height)
+				}
+			}
+			if !slices.Equal(widths, tc.wantWidths) || !slices.Equal(heights, tc.wantHeights) {
+				t.Errorf("got widths %v and heights %v, want %v and %v", widths, heights, tc.wantWidths, tc.wantHeights)
+			}
+		})
+	}
+}
+
+func TestResize_tooLarge(t *testing.T) {
+	defer func(n int) { maxPixels = n }(maxPixels)
+	maxPixels = 100 * 100
+
+	if _, err := Resize(encodePNG(t, 101, 100, 255)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("got error %v, want %v", err, ErrTooLarge)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var photo bytes.Buffer
+	if err := jpeg.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 1000, 500)), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	img, err := Store(ctx, store, photo.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(img.Variants) != 3 || img.Largest().Width != 1000 {
+		t.Fatalf("got variants %v", img.Keys())
+	}
+	for _, key := range img.Keys() {
+		b, err := store.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("variant %s is not stored: %v", key, err)
+		}
+		if b.ContentType != "image/jpeg" {
+			t.Errorf("got content type %q for %s", b.ContentType, key)
+		}
+	}
+
+	if err := Delete(ctx, store, img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range img.Keys() {
+		if _, err := store.Get(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+			t.Errorf("variant %s was not deleted: %v", key, err)
+		}
+	}
+}
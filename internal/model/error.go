@@ -8,4 +8,9 @@ type ErrorReason int
 const (
 	ErrorReasonDeadline ErrorReason = iota
 	ErrorReasonProcess
+	ErrorReasonPolicy
+	ErrorReasonAnswer
+	ErrorReasonFullyBooked
+	ErrorReasonGiftUnavailable
+	ErrorReasonTravel
 )
@@ -4,16 +4,104 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// DefaultMaxInvitations is the invitation limit of an event without an
+// explicit MaxInvitations setting.
+const DefaultMaxInvitations = 250
+
+var ErrInvalidTimezone = errors.New("invalid time zone")
+
+// DefaultTimezone is the time zone of events without a valid Timezone
+// setting. Dates used to be shown in CET, which it matches.
+const DefaultTimezone = "Europe/Berlin"
+
+var (
+	// DefaultDietaryTags are offered to guests if the event does not configure
+	// its own dietary tags.
+	DefaultDietaryTags = []string{"vegan", "vegetarian", "pescatarian", "halal", "kosher"}
+	// DefaultAllergens are offered to guests if the event does not configure
+	// its own allergens.
+	DefaultAllergens = []string{"nuts", "peanuts", "gluten", "lactose", "eggs", "fish", "shellfish", "soy"}
+)
+
 type Event struct {
 	*Location
-	Date     time.Time   `json:"date" form:"date"`
-	Hotels   []*Location `json:"hotels,omitempty" form:"hotels"`
-	Airports []*Location `json:"airports,omitempty" form:"airports"`
+	Date time.Time `json:"date" form:"date"`
+	// Timezone is the IANA name of the time zone dates are entered and shown
+	// in, e.g. "Europe/Berlin".
+	Timezone       string      `json:"timezone,omitempty" form:"-"`
+	MaxInvitations int         `json:"max_invitations,omitempty" form:"max_invitations"`
+	Capacity       int         `json:"capacity,omitempty" form:"capacity"`
+	Deadline       *time.Time  `json:"deadline,omitempty" form:"-"`
+	DietaryTags    []string    `json:"dietary_tags,omitempty" form:"-"`
+	Allergens      []string    `json:"allergens,omitempty" form:"-"`
+	Questions      []*Question `json:"questions,omitempty" form:"-"`
+	SubEvents      []*SubEvent `json:"sub_events,omitempty" form:"-"`
+	Gifts          []*Gift     `json:"gifts,omitempty" form:"-"`
+	Hotels         []*Location `json:"hotels,omitempty" form:"hotels"`
+	Airports       []*Location `json:"airports,omitempty" form:"airports"`
+	Theme          *Theme      `json:"theme,omitempty" form:"-"`
+}
+
+// InvitationLimit returns the maximum number of invitations of the event.
+func (e *Event) InvitationLimit() int {
+	if e.MaxInvitations > 0 {
+		return e.MaxInvitations
+	}
+	return DefaultMaxInvitations
+}
+
+// LoadTimezone returns the time zone with the given IANA name. Unlike
+// time.LoadLocation, it rejects the empty name, which would be UTC, and
+// "Local", which depends on the server.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidTimezone, err)
+	}
+	return loc, nil
+}
+
+// TimeZone returns the time zone of the event.
+func (e *Event) TimeZone() *time.Location {
+	if loc, err := LoadTimezone(e.Timezone); err == nil {
+		return loc
+	}
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// HasSeats reports whether another guest fits into the venue, given the number
+// of guests that already accepted. A capacity of zero means unlimited.
+func (e *Event) HasSeats(accepted int) bool {
+	return e.Capacity <= 0 || accepted < e.Capacity
+}
+
+// DietaryTagOptions returns the dietary tags guests can choose from.
+func (e *Event) DietaryTagOptions() []string {
+	if len(e.DietaryTags) > 0 {
+		return e.DietaryTags
+	}
+	return DefaultDietaryTags
+}
+
+// AllergenOptions returns the allergens guests can choose from.
+func (e *Event) AllergenOptions() []string {
+	if len(e.Allergens) > 0 {
+		return e.Allergens
+	}
+	return DefaultAllergens
 }
 
 type Location struct {
@@ -30,4 +118,8 @@ type Location struct {
 	Longitude    float64    `json:"longitude,omitempty" form:"longitude"`
 	Latitude     float64    `json:"latitude,omitempty" form:"latitude"`
 	Website      string     `json:"website,omitempty" form:"website"`
+	// RoomBlock is the number of rooms reserved for the guests, if the
+	// location is a hotel. Zero means there is no room block.
+	RoomBlock int      `json:"room_block,omitempty" form:"room_block"`
+	Photos    []*Image `json:"photos,omitempty" form:"-"`
 }
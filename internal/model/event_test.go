@@ -0,0 +1,54 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"testing"
+	_ "time/tzdata"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	tt := []struct {
+		name    string
+		wantErr error
+	}{
+		{name: "Europe/Berlin"},
+		{name: "America/New_York"},
+		{name: "UTC"},
+		{name: "", wantErr: ErrInvalidTimezone},
+		{name: "Local", wantErr: ErrInvalidTimezone},
+		{name: "Mars/Olympus_Mons", wantErr: ErrInvalidTimezone},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			loc, err := LoadTimezone(tc.name)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			if err == nil && loc.String() != tc.name {
+				t.Fatalf("got %q, want %q", loc, tc.name)
+			}
+		})
+	}
+}
+
+func TestEvent_TimeZone(t *testing.T) {
+	tt := []struct {
+		timezone string
+		want     string
+	}{
+		{timezone: "America/New_York", want: "America/New_York"},
+		{timezone: "", want: DefaultTimezone},
+		{timezone: "invalid", want: DefaultTimezone},
+	}
+
+	for _, tc := range tt {
+		e := Event{Timezone: tc.timezone}
+		if got := e.TimeZone().String(); got != tc.want {
+			t.Errorf("Event{Timezone: %q}.TimeZone() = %q, want %q", tc.timezone, got, tc.want)
+		}
+	}
+}
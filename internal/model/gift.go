@@ -0,0 +1,79 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrGiftUnavailable = errors.New("gift is not available anymore")
+
+// Gift is an item of the gift registry of the event. Claims are counted per
+// invitation and never shown to other guests.
+type Gift struct {
+	ID       uuid.UUID         `json:"id" form:"-"`
+	Title    string            `json:"title" form:"title"`
+	Link     string            `json:"link,omitempty" form:"link"`
+	Price    float64           `json:"price,omitempty" form:"price"`
+	Quantity int               `json:"quantity" form:"quantity"`
+	Claims   map[uuid.UUID]int `json:"claims,omitempty" form:"-"`
+}
+
+// Claimed returns the number of claimed items.
+func (g *Gift) Claimed() int {
+	var res int
+	for _, n := range g.Claims {
+		res += n
+	}
+	return res
+}
+
+// Available returns the number of items that can still be claimed.
+func (g *Gift) Available() int {
+	return max(g.Quantity-g.Claimed(), 0)
+}
+
+// ClaimedBy returns the number of items claimed by the given invitation.
+func (g *Gift) ClaimedBy(inviteID uuid.UUID) int {
+	return g.Claims[inviteID]
+}
+
+// Claim claims one item for the given invitation.
+func (g *Gift) Claim(inviteID uuid.UUID) error {
+	if g.Available() == 0 {
+		return ErrGiftUnavailable
+	}
+	if g.Claims == nil {
+		g.Claims = make(map[uuid.UUID]int)
+	}
+	g.Claims[inviteID]++
+	return nil
+}
+
+// Release releases one item claimed by the given invitation and reports
+// whether there was one.
+func (g *Gift) Release(inviteID uuid.UUID) bool {
+	n := g.Claims[inviteID]
+	switch {
+	case n <= 0:
+		return false
+	case n == 1:
+		delete(g.Claims, inviteID)
+	default:
+		g.Claims[inviteID] = n - 1
+	}
+	return true
+}
+
+// Gift returns the gift with the given ID, or nil if there is none.
+func (e *Event) Gift(id uuid.UUID) *Gift {
+	for _, g := range e.Gifts {
+		if g.ID == id {
+			return g
+		}
+	}
+	return nil
+}
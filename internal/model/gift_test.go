@@ -0,0 +1,53 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGift_Claim(t *testing.T) {
+	a := uuid.MustParse("0eac703a-40f3-4318-ae96-f28e026a23c6")
+	b := uuid.MustParse("b5627acd-9332-476c-8466-f49de1567865")
+
+	gift := Gift{Quantity: 2}
+	if err := gift.Claim(a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := gift.Claim(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := gift.Claim(a); !errors.Is(err, ErrGiftUnavailable) {
+		t.Fatalf("got error %v, want %v", err, ErrGiftUnavailable)
+	}
+	if got := gift.ClaimedBy(a); got != 1 {
+		t.Fatalf("got %d claims, want 1", got)
+	}
+
+	if !gift.Release(a) {
+		t.Fatal("claim was not released")
+	}
+	if gift.Release(a) {
+		t.Fatal("released a claim that did not exist")
+	}
+	if got := gift.Available(); got != 1 {
+		t.Fatalf("got %d available, want 1", got)
+	}
+	if _, ok := gift.Claims[a]; ok {
+		t.Fatal("released invitation is still listed")
+	}
+}
+
+func TestGift_Available(t *testing.T) {
+	a := uuid.MustParse("0eac703a-40f3-4318-ae96-f28e026a23c6")
+
+	// NOTE: never report a negative number of available items.
+	gift := Gift{Quantity: 1, Claims: map[uuid.UUID]int{a: 3}}
+	if got := gift.Available(); got != 0 {
+		t.Fatalf("got %d available, want 0", got)
+	}
+}
@@ -4,6 +4,8 @@
 package model
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,8 +27,20 @@ const (
 	InvitationStatusAccepted
 	InvitationStatusRejected
 	InvitationStatusNotAnswered
+	InvitationStatusWaitlisted
 )
 
+// GuestMaySetStatus reports whether guests may change their own status from
+// previous to status. They may answer the invitation or keep their status,
+// but only admins and the capacity of the event put them on the waitlist.
+func GuestMaySetStatus(previous, status InvitationStatus) bool {
+	switch status {
+	case previous, InvitationStatusAccepted, InvitationStatusRejected, InvitationStatusNotAnswered:
+		return true
+	}
+	return false
+}
+
 type GuestAgeCategory int
 
 const (
@@ -36,14 +50,52 @@ const (
 	GuestAgeCategoryAdult
 )
 
+// IsChild reports whether the age category describes a minor.
+func (a GuestAgeCategory) IsChild() bool {
+	return a == GuestAgeCategoryBaby || a == GuestAgeCategoryTeenager
+}
+
 type Guest struct {
-	ID               uuid.UUID        `json:"id" form:"-"`
-	Deleteable       bool             `json:"deleteable" form:"-"`
-	CreatedAt        *time.Time       `json:"created_at" form:"-"`
-	UpdatedAt        *time.Time       `json:"updated_at" form:"-"`
-	Firstname        string           `json:"firstname" form:"firstname"`
-	Lastname         string           `json:"lastname" form:"lastname"`
-	AgeCategory      GuestAgeCategory `json:"age_category" form:"age_category"`
-	DietaryCategory  DietaryCategory  `json:"dietary_category" form:"dietary_category"`
-	InvitationStatus InvitationStatus `json:"invitation_status" form:"invitation_status"`
+	ID               uuid.UUID                   `json:"id" form:"-"`
+	Deleteable       bool                        `json:"deleteable" form:"-"`
+	CreatedAt        *time.Time                  `json:"created_at" form:"-"`
+	UpdatedAt        *time.Time                  `json:"updated_at" form:"-"`
+	Firstname        string                      `json:"firstname" form:"firstname"`
+	Lastname         string                      `json:"lastname" form:"lastname"`
+	AgeCategory      GuestAgeCategory            `json:"age_category" form:"age_category"`
+	DietaryCategory  DietaryCategory             `json:"dietary_category" form:"dietary_category"`
+	InvitationStatus InvitationStatus            `json:"invitation_status" form:"invitation_status"`
+	DietaryTags      []string                    `json:"dietary_tags,omitempty" form:"dietary_tags"`
+	Allergens        []string                    `json:"allergens,omitempty" form:"allergens"`
+	DietaryNote      string                      `json:"dietary_note,omitempty" form:"dietary_note"`
+	Answers          map[string][]string         `json:"answers,omitempty" form:"answers"`
+	SubEvents        map[string]InvitationStatus `json:"sub_events,omitempty" form:"sub_events"`
+	Travel           Travel                      `json:"travel" form:"travel"`
+	WaitlistedAt     *time.Time                  `json:"waitlisted_at,omitempty" form:"-"`
+}
+
+// HasDietaryTag reports whether the guest selected the given dietary tag.
+func (g *Guest) HasDietaryTag(tag string) bool {
+	return slices.Contains(g.DietaryTags, tag)
+}
+
+// HasAllergen reports whether the guest is allergic to the given allergen.
+func (g *Guest) HasAllergen(allergen string) bool {
+	return slices.Contains(g.Allergens, allergen)
+}
+
+// Answer returns the answer of the guest to the given question. Multiple
+// values are joined by a comma.
+func (g *Guest) Answer(questionID string) string {
+	return strings.Join(g.Answers[questionID], ", ")
+}
+
+// HasAnswer reports whether the guest answered the given question with value.
+func (g *Guest) HasAnswer(questionID, value string) bool {
+	return slices.Contains(g.Answers[questionID], value)
+}
+
+// SubEventStatus returns the answer of the guest for the given sub-event.
+func (g *Guest) SubEventStatus(subEventID string) InvitationStatus {
+	return g.SubEvents[subEventID]
 }
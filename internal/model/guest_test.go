@@ -0,0 +1,32 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import "testing"
+
+func TestGuestMaySetStatus(t *testing.T) {
+	tt := []struct {
+		name     string
+		previous InvitationStatus
+		status   InvitationStatus
+		want     bool
+	}{
+		{name: "accept", previous: InvitationStatusNotAnswered, status: InvitationStatusAccepted, want: true},
+		{name: "reject", previous: InvitationStatusAccepted, status: InvitationStatusRejected, want: true},
+		{name: "undecided", previous: InvitationStatusRejected, status: InvitationStatusNotAnswered, want: true},
+		{name: "leave waitlist", previous: InvitationStatusWaitlisted, status: InvitationStatusRejected, want: true},
+		{name: "stay on waitlist", previous: InvitationStatusWaitlisted, status: InvitationStatusWaitlisted, want: true},
+		{name: "keep unknown", previous: InvitationStatusUnknown, status: InvitationStatusUnknown, want: true},
+		{name: "join waitlist", previous: InvitationStatusNotAnswered, status: InvitationStatusWaitlisted},
+		{name: "unknown", previous: InvitationStatusAccepted, status: InvitationStatusUnknown},
+		{name: "out of range", previous: InvitationStatusNotAnswered, status: 42},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := GuestMaySetStatus(tc.previous, tc.status); got != tc.want {
+				t.Errorf("GuestMaySetStatus(%d, %d) = %v, want %v", tc.previous, tc.status, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Image is an uploaded image, stored in several widths so browsers can pick
+// the smallest one that fits.
+type Image struct {
+	ID uuid.UUID `json:"id"`
+	// Variants are ordered by ascending width.
+	Variants []*ImageVariant `json:"variants"`
+}
+
+// ImageVariant is an image scaled to a width. Key refers to the blob store.
+type ImageVariant struct {
+	Key    string `json:"key"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// Largest returns the widest variant, or nil if there is none.
+func (i *Image) Largest() *ImageVariant {
+	if i == nil || len(i.Variants) == 0 {
+		return nil
+	}
+	return i.Variants[len(i.Variants)-1]
+}
+
+// Keys returns the blob keys of all variants.
+func (i *Image) Keys() []string {
+	if i == nil {
+		return nil
+	}
+	keys := make([]string, len(i.Variants))
+	for n, v := range i.Variants {
+		keys[n] = v.Key
+	}
+	return keys
+}
@@ -3,11 +3,55 @@
 
 package model
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMaxGuests is the guest limit of an invitation without an explicit
+// MaxGuests setting.
+const DefaultMaxGuests = 10
+
+var (
+	ErrMaxGuestsExceeded   = errors.New("maximum number of guests exceeded")
+	ErrMaxPlusOnesExceeded = errors.New("maximum number of plus-ones exceeded")
+	ErrPlusOnesNotAllowed  = errors.New("plus-ones are not allowed")
+	ErrChildrenNotAllowed  = errors.New("children are not allowed")
+)
+
+// InvitationPolicy controls whether guests may bring along a certain kind of
+// additional guest. The zero value keeps the default, which is to allow it.
+type InvitationPolicy int
+
+const (
+	InvitationPolicyUnknown InvitationPolicy = iota
+	InvitationPolicyAllowed
+	InvitationPolicyDenied
+)
+
+func (p InvitationPolicy) Allowed() bool {
+	return p != InvitationPolicyDenied
+}
 
 type Invitation struct {
 	ID       uuid.UUID
 	GuestIDs []uuid.UUID
+	// MaxGuests limits the total number of guests, including plus-ones.
+	MaxGuests int `json:"max_guests,omitempty" form:"max_guests"`
+	// MaxPlusOnes limits the number of guests that can be added by the
+	// invited guests themselves. Zero means no limit besides MaxGuests.
+	MaxPlusOnes int `json:"max_plus_ones,omitempty" form:"max_plus_ones"`
+	// PlusOnes decides whether the invited guests can add guests at all.
+	PlusOnes InvitationPolicy `json:"plus_ones,omitempty" form:"plus_ones"`
+	// Children decides whether added guests can be children.
+	Children InvitationPolicy `json:"children,omitempty" form:"children"`
+	// DeadlineExtension allows the guests to answer after the event deadline.
+	DeadlineExtension *time.Time `json:"deadline_extension,omitempty" form:"-"`
+	// DefaultLanguage is shown to the guests unless they chose another
+	// language. Empty means the language is negotiated with their browser.
+	DefaultLanguage string `json:"default_language,omitempty" form:"default_language"`
 }
 
 func (i *Invitation) RemoveGuest(id uuid.UUID) {
@@ -18,3 +62,71 @@ func (i *Invitation) RemoveGuest(id uuid.UUID) {
 		}
 	}
 }
+
+// GuestLimit returns the maximum number of guests of the invitation.
+func (i *Invitation) GuestLimit() int {
+	if i.MaxGuests > 0 {
+		return i.MaxGuests
+	}
+	return DefaultMaxGuests
+}
+
+// CanAddPlusOne reports whether another plus-one fits into the invitation,
+// given the number of plus-ones it already has.
+func (i *Invitation) CanAddPlusOne(plusOnes int) error {
+	if !i.PlusOnes.Allowed() {
+		return ErrPlusOnesNotAllowed
+	}
+	if len(i.GuestIDs) >= i.GuestLimit() {
+		return ErrMaxGuestsExceeded
+	}
+	if i.MaxPlusOnes > 0 && plusOnes >= i.MaxPlusOnes {
+		return ErrMaxPlusOnesExceeded
+	}
+	return nil
+}
+
+// CheckGuest verifies that a guest of the invitation complies with its
+// plus-one and children policies. Invited guests are always accepted.
+func (i *Invitation) CheckGuest(g *Guest) error {
+	if !g.Deleteable {
+		return nil
+	}
+	if !i.PlusOnes.Allowed() {
+		return ErrPlusOnesNotAllowed
+	}
+	if g.AgeCategory.IsChild() && !i.Children.Allowed() {
+		return ErrChildrenNotAllowed
+	}
+	return nil
+}
+
+// HasGuest reports whether the guest belongs to the invitation.
+func (i *Invitation) HasGuest(id uuid.UUID) bool {
+	for _, gid := range i.GuestIDs {
+		if gid == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ResponseDeadline returns the deadline for answering the invitation, given
+// the deadline of the event. An extension can only postpone the deadline.
+func (i *Invitation) ResponseDeadline(deadline time.Time) time.Time {
+	if deadline.IsZero() || i.DeadlineExtension == nil || i.DeadlineExtension.Before(deadline) {
+		return deadline
+	}
+	return *i.DeadlineExtension
+}
+
+// Validate checks the invitation against its configured limits.
+func (i *Invitation) Validate() error {
+	if i.MaxGuests < 0 || i.MaxPlusOnes < 0 {
+		return errors.New("invitation limits must not be negative")
+	}
+	if len(i.GuestIDs) > i.GuestLimit() {
+		return ErrMaxGuestsExceeded
+	}
+	return nil
+}
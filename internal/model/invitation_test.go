@@ -4,7 +4,9 @@
 package model
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -68,3 +70,185 @@ func TestInvitation_RemoveGuest(t *testing.T) {
 		})
 	}
 }
+
+func TestInvitation_ResponseDeadline(t *testing.T) {
+	deadline := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	earlier := deadline.Add(-24 * time.Hour)
+	later := deadline.Add(24 * time.Hour)
+
+	tt := []struct {
+		name     string
+		invite   Invitation
+		deadline time.Time
+		want     time.Time
+	}{
+		{
+			name:     "no extension",
+			invite:   Invitation{},
+			deadline: deadline,
+			want:     deadline,
+		},
+		{
+			name:     "extended",
+			invite:   Invitation{DeadlineExtension: &later},
+			deadline: deadline,
+			want:     later,
+		},
+		{
+			name:     "extension before deadline",
+			invite:   Invitation{DeadlineExtension: &earlier},
+			deadline: deadline,
+			want:     deadline,
+		},
+		{
+			name:     "no deadline",
+			invite:   Invitation{DeadlineExtension: &later},
+			deadline: time.Time{},
+			want:     time.Time{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.invite.ResponseDeadline(tc.deadline); !got.Equal(tc.want) {
+				t.Fatalf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// guestIDs returns n new guest IDs.
+func guestIDs(n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	return ids
+}
+
+func TestInvitation_GuestLimit(t *testing.T) {
+	tt := []struct {
+		name   string
+		invite Invitation
+		want   int
+	}{
+		{name: "default", invite: Invitation{}, want: DefaultMaxGuests},
+		{name: "configured", invite: Invitation{MaxGuests: 4}, want: 4},
+		{name: "above default", invite: Invitation{MaxGuests: 25}, want: 25},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.invite.GuestLimit(); got != tc.want {
+				t.Fatalf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInvitation_CanAddPlusOne_Limits(t *testing.T) {
+	tt := []struct {
+		name     string
+		invite   Invitation
+		plusOnes int
+		wantErr  error
+	}{
+		{name: "below default limit", invite: Invitation{GuestIDs: guestIDs(DefaultMaxGuests - 1)}},
+		{name: "default limit", invite: Invitation{GuestIDs: guestIDs(DefaultMaxGuests)}, wantErr: ErrMaxGuestsExceeded},
+		{name: "below guest limit", invite: Invitation{GuestIDs: guestIDs(2), MaxGuests: 3}, plusOnes: 1},
+		{name: "guest limit", invite: Invitation{GuestIDs: guestIDs(3), MaxGuests: 3}, plusOnes: 1, wantErr: ErrMaxGuestsExceeded},
+		{name: "below plus-one limit", invite: Invitation{GuestIDs: guestIDs(2), MaxPlusOnes: 2}, plusOnes: 1},
+		{name: "plus-one limit", invite: Invitation{GuestIDs: guestIDs(3), MaxPlusOnes: 2}, plusOnes: 2, wantErr: ErrMaxPlusOnesExceeded},
+		{name: "guest limit before plus-one limit", invite: Invitation{GuestIDs: guestIDs(2), MaxGuests: 2, MaxPlusOnes: 1}, plusOnes: 1, wantErr: ErrMaxGuestsExceeded},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.invite.CanAddPlusOne(tc.plusOnes); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestInvitation_Validate(t *testing.T) {
+	tt := []struct {
+		name    string
+		invite  Invitation
+		wantErr bool
+	}{
+		{name: "default", invite: Invitation{GuestIDs: guestIDs(DefaultMaxGuests)}},
+		{name: "above default", invite: Invitation{GuestIDs: guestIDs(DefaultMaxGuests + 1)}, wantErr: true},
+		{name: "configured", invite: Invitation{GuestIDs: guestIDs(12), MaxGuests: 12, MaxPlusOnes: 3}},
+		{name: "above configured", invite: Invitation{GuestIDs: guestIDs(3), MaxGuests: 2}, wantErr: true},
+		{name: "negative guests", invite: Invitation{MaxGuests: -1}, wantErr: true},
+		{name: "negative plus-ones", invite: Invitation{MaxPlusOnes: -1}, wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.invite.Validate(); (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestInvitation_CanAddPlusOne_Policy(t *testing.T) {
+	tt := []struct {
+		name     string
+		plusOnes InvitationPolicy
+		wantErr  error
+	}{
+		{name: "unknown", plusOnes: InvitationPolicyUnknown},
+		{name: "allowed", plusOnes: InvitationPolicyAllowed},
+		{name: "denied", plusOnes: InvitationPolicyDenied, wantErr: ErrPlusOnesNotAllowed},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			invite := Invitation{GuestIDs: guestIDs(1), PlusOnes: tc.plusOnes}
+			if err := invite.CanAddPlusOne(0); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	// NOTE: the children policy only applies to the guests once they are
+	// added, see CheckGuest.
+	invite := Invitation{GuestIDs: guestIDs(1), Children: InvitationPolicyDenied}
+	if err := invite.CanAddPlusOne(0); err != nil {
+		t.Fatalf("got error %v for denied children", err)
+	}
+}
+
+func TestInvitation_CheckGuest(t *testing.T) {
+	tt := []struct {
+		name     string
+		plusOnes InvitationPolicy
+		children InvitationPolicy
+		guest    Guest
+		wantErr  error
+	}{
+		{name: "invited guest", plusOnes: InvitationPolicyDenied, children: InvitationPolicyDenied, guest: Guest{AgeCategory: GuestAgeCategoryBaby}},
+		{name: "plus-one by default", guest: Guest{Deleteable: true, AgeCategory: GuestAgeCategoryAdult}},
+		{name: "plus-one allowed", plusOnes: InvitationPolicyAllowed, guest: Guest{Deleteable: true}},
+		{name: "plus-one denied", plusOnes: InvitationPolicyDenied, guest: Guest{Deleteable: true, AgeCategory: GuestAgeCategoryAdult}, wantErr: ErrPlusOnesNotAllowed},
+		{name: "plus-one denied before children", plusOnes: InvitationPolicyDenied, children: InvitationPolicyDenied, guest: Guest{Deleteable: true, AgeCategory: GuestAgeCategoryBaby}, wantErr: ErrPlusOnesNotAllowed},
+		{name: "child by default", guest: Guest{Deleteable: true, AgeCategory: GuestAgeCategoryTeenager}},
+		{name: "child allowed", children: InvitationPolicyAllowed, guest: Guest{Deleteable: true, AgeCategory: GuestAgeCategoryBaby}},
+		{name: "baby denied", children: InvitationPolicyDenied, guest: Guest{Deleteable: true, AgeCategory: GuestAgeCategoryBaby}, wantErr: ErrChildrenNotAllowed},
+		{name: "teenager denied", children: InvitationPolicyDenied, guest: Guest{Deleteable: true, AgeCategory: GuestAgeCategoryTeenager}, wantErr: ErrChildrenNotAllowed},
+		{name: "adult with children denied", children: InvitationPolicyDenied, guest: Guest{Deleteable: true, AgeCategory: GuestAgeCategoryAdult}},
+		{name: "unknown age with children denied", children: InvitationPolicyDenied, guest: Guest{Deleteable: true}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			invite := Invitation{PlusOnes: tc.plusOnes, Children: tc.children}
+			if err := invite.CheckGuest(&tc.guest); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAnswerRequired = errors.New("answer is required")
+	ErrInvalidAnswer  = errors.New("invalid answer")
+)
+
+type QuestionType int
+
+const (
+	QuestionTypeUnknown QuestionType = iota
+	QuestionTypeText
+	QuestionTypeSelect
+	QuestionTypeMultiSelect
+	QuestionTypeBoolean
+	QuestionTypeNumber
+)
+
+// Question is an additional question the host asks every guest, e.g. for a
+// song request or the t-shirt size. Labels are keyed by language.
+type Question struct {
+	ID       uuid.UUID         `json:"id" form:"-"`
+	Type     QuestionType      `json:"type" form:"type"`
+	Required bool              `json:"required,omitempty" form:"required"`
+	Labels   map[string]string `json:"labels,omitempty" form:"labels"`
+	Options  []*QuestionOption `json:"options,omitempty" form:"-"`
+}
+
+// QuestionOption is a choice of a select or multi-select question. Labels are
+// keyed by language.
+type QuestionOption struct {
+	Value  string            `json:"value"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Label returns the label of the question in the given language. If there is
+// no label in that language, any other label is used.
+func (q *Question) Label(lang string) string {
+	return localized(q.Labels, lang, q.ID.String())
+}
+
+// Label returns the label of the option in the given language. If there is no
+// label in that language, any other label is used.
+func (o *QuestionOption) Label(lang string) string {
+	return localized(o.Labels, lang, o.Value)
+}
+
+func localized(labels map[string]string, lang, fallback string) string {
+	if label := labels[lang]; label != "" {
+		return label
+	}
+	langs := make([]string, 0, len(labels))
+	for l := range labels {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	for _, l := range langs {
+		if labels[l] != "" {
+			return labels[l]
+		}
+	}
+	return fallback
+}
+
+// HasOption reports whether value is one of the options of the question.
+func (q *Question) HasOption(value string) bool {
+	return slices.ContainsFunc(q.Options, func(o *QuestionOption) bool {
+		return o.Value == value
+	})
+}
+
+// Check validates the submitted values of an answer and returns them in
+// normalized form. Empty values are dropped. If required is set, an answer
+// must be given.
+func (q *Question) Check(values []string, required bool) ([]string, error) {
+	var res []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !slices.Contains(res, v) {
+			res = append(res, v)
+		}
+	}
+	if len(res) == 0 {
+		if required {
+			return nil, fmt.Errorf("%w: %s", ErrAnswerRequired, q.ID)
+		}
+		return nil, nil
+	}
+
+	switch q.Type {
+	case QuestionTypeText:
+		return res[:1], nil
+	case QuestionTypeNumber:
+		if len(res) > 1 {
+			return nil, fmt.Errorf("%w: %s expects a single number", ErrInvalidAnswer, q.ID)
+		}
+		if _, err := strconv.ParseFloat(res[0], 64); err != nil {
+			return nil, fmt.Errorf("%w: %s expects a number", ErrInvalidAnswer, q.ID)
+		}
+	case QuestionTypeBoolean:
+		if len(res) > 1 {
+			return nil, fmt.Errorf("%w: %s expects a single value", ErrInvalidAnswer, q.ID)
+		}
+		b, err := strconv.ParseBool(res[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s expects yes or no", ErrInvalidAnswer, q.ID)
+		}
+		res[0] = strconv.FormatBool(b)
+	case QuestionTypeSelect, QuestionTypeMultiSelect:
+		if q.Type == QuestionTypeSelect && len(res) > 1 {
+			return nil, fmt.Errorf("%w: %s expects a single option", ErrInvalidAnswer, q.ID)
+		}
+		for _, v := range res {
+			if !q.HasOption(v) {
+				return nil, fmt.Errorf("%w: %s has no option %q", ErrInvalidAnswer, q.ID, v)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s has an unknown type", ErrInvalidAnswer, q.ID)
+	}
+	return res, nil
+}
+
+// CheckAnswers validates the answers of a guest to the questions of the event
+// and returns them in normalized form, keyed by question ID. Answers to
+// unknown questions are dropped. Required questions must only be answered by
+// guests that accepted the invitation.
+func (e *Event) CheckAnswers(g *Guest) (map[string][]string, error) {
+	required := g.InvitationStatus == InvitationStatusAccepted
+	res := make(map[string][]string, len(e.Questions))
+	for _, q := range e.Questions {
+		values, err := q.Check(g.Answers[q.ID.String()], required && q.Required)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) > 0 {
+			res[q.ID.String()] = values
+		}
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res, nil
+}
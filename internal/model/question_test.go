@@ -0,0 +1,99 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestQuestion_Check(t *testing.T) {
+	options := []*QuestionOption{{Value: "s"}, {Value: "m"}, {Value: "l"}}
+
+	tt := []struct {
+		name     string
+		question Question
+		values   []string
+		required bool
+		want     []string
+		wantErr  error
+	}{
+		{
+			name:     "empty optional",
+			question: Question{Type: QuestionTypeText},
+			values:   []string{""},
+		},
+		{
+			name:     "empty required",
+			question: Question{Type: QuestionTypeText},
+			values:   []string{"", " "},
+			required: true,
+			wantErr:  ErrAnswerRequired,
+		},
+		{
+			name:     "text",
+			question: Question{Type: QuestionTypeText},
+			values:   []string{" Dancing Queen "},
+			want:     []string{"Dancing Queen"},
+		},
+		{
+			name:     "number",
+			question: Question{Type: QuestionTypeNumber},
+			values:   []string{"2.5"},
+			want:     []string{"2.5"},
+		},
+		{
+			name:     "not a number",
+			question: Question{Type: QuestionTypeNumber},
+			values:   []string{"two"},
+			wantErr:  ErrInvalidAnswer,
+		},
+		{
+			name:     "boolean",
+			question: Question{Type: QuestionTypeBoolean},
+			values:   []string{"", "1"},
+			want:     []string{"true"},
+		},
+		{
+			name:     "select",
+			question: Question{Type: QuestionTypeSelect, Options: options},
+			values:   []string{"m"},
+			want:     []string{"m"},
+		},
+		{
+			name:     "select multiple",
+			question: Question{Type: QuestionTypeSelect, Options: options},
+			values:   []string{"m", "l"},
+			wantErr:  ErrInvalidAnswer,
+		},
+		{
+			name:     "unknown option",
+			question: Question{Type: QuestionTypeMultiSelect, Options: options},
+			values:   []string{"m", "xl"},
+			wantErr:  ErrInvalidAnswer,
+		},
+		{
+			name:     "multi-select",
+			question: Question{Type: QuestionTypeMultiSelect, Options: options},
+			values:   []string{"", "s", "l", "s"},
+			want:     []string{"s", "l"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.question.ID = uuid.New()
+			got, err := tc.question.Check(tc.values, tc.required)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrSubEventFull = errors.New("sub-event is fully booked")
+
+// SubEvent is a part of a multi-day event, e.g. the rehearsal dinner or the
+// brunch on the day after. Labels are keyed by language.
+type SubEvent struct {
+	ID       uuid.UUID         `json:"id" form:"-"`
+	Labels   map[string]string `json:"labels,omitempty" form:"labels"`
+	Date     time.Time         `json:"date" form:"-"`
+	Location Location          `json:"location" form:"location"`
+	Capacity int               `json:"capacity,omitempty" form:"capacity"`
+	// Invitations restricts the sub-event to the given invitations. An empty
+	// list invites everyone.
+	Invitations []uuid.UUID `json:"invitations,omitempty" form:"-"`
+}
+
+// Label returns the label of the sub-event in the given language. If there is
+// no label in that language, any other label is used.
+func (s *SubEvent) Label(lang string) string {
+	return localized(s.Labels, lang, s.Location.Name)
+}
+
+// Invites reports whether the guests of the given invitation are invited to
+// the sub-event.
+func (s *SubEvent) Invites(inviteID uuid.UUID) bool {
+	return len(s.Invitations) == 0 || slices.Contains(s.Invitations, inviteID)
+}
+
+// HasSeats reports whether another guest fits into the sub-event, given the
+// number of guests that already accepted. A capacity of zero means unlimited.
+func (s *SubEvent) HasSeats(accepted int) bool {
+	return s.Capacity <= 0 || accepted < s.Capacity
+}
+
+// SubEventsFor returns the sub-events the guests of the given invitation are
+// invited to.
+func (e *Event) SubEventsFor(inviteID uuid.UUID) []*SubEvent {
+	var res []*SubEvent
+	for _, s := range e.SubEvents {
+		if s.Invites(inviteID) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrTableFull = errors.New("table is full")
+
+type TableShape int
+
+const (
+	TableShapeUnknown TableShape = iota
+	TableShapeRound
+	TableShapeRectangular
+	TableShapeSquare
+)
+
+// Table is a table of the seating plan together with the guests assigned to
+// it.
+type Table struct {
+	ID        uuid.UUID  `json:"id" form:"-"`
+	CreatedAt *time.Time `json:"created_at" form:"-"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" form:"-"`
+	Name      string     `json:"name,omitempty" form:"name"`
+	// Capacity is the number of seats. Zero means unlimited.
+	Capacity int         `json:"capacity,omitempty" form:"capacity"`
+	Shape    TableShape  `json:"shape,omitempty" form:"shape"`
+	GuestIDs []uuid.UUID `json:"guest_ids,omitempty" form:"-"`
+}
+
+// Seats reports whether the guest is assigned to the table.
+func (t *Table) Seats(guestID uuid.UUID) bool {
+	return slices.Contains(t.GuestIDs, guestID)
+}
+
+// Seat assigns the given guests to the table. Guests that are already
+// assigned are skipped. If the guests do not fit, none of them is assigned.
+func (t *Table) Seat(guestIDs ...uuid.UUID) error {
+	var add []uuid.UUID
+	for _, id := range guestIDs {
+		if !t.Seats(id) && !slices.Contains(add, id) {
+			add = append(add, id)
+		}
+	}
+	if t.Capacity > 0 && len(t.GuestIDs)+len(add) > t.Capacity {
+		return ErrTableFull
+	}
+	t.GuestIDs = append(t.GuestIDs, add...)
+	return nil
+}
+
+// Unseat removes the given guests from the table and reports whether any of
+// them was assigned to it.
+func (t *Table) Unseat(guestIDs ...uuid.UUID) bool {
+	n := len(t.GuestIDs)
+	t.GuestIDs = slices.DeleteFunc(t.GuestIDs, func(id uuid.UUID) bool {
+		return slices.Contains(guestIDs, id)
+	})
+	return len(t.GuestIDs) != n
+}
+
+// Validate checks that the assigned guests fit the table.
+func (t *Table) Validate() error {
+	if t.Capacity > 0 && len(t.GuestIDs) > t.Capacity {
+		return ErrTableFull
+	}
+	return nil
+}
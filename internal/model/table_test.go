@@ -0,0 +1,80 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTable_Seat(t *testing.T) {
+	a := uuid.MustParse("0eac703a-40f3-4318-ae96-f28e026a23c6")
+	b := uuid.MustParse("b5627acd-9332-476c-8466-f49de1567865")
+	c := uuid.MustParse("951812f2-9bbd-481b-a798-6653c355b9c0")
+
+	tt := []struct {
+		name    string
+		table   Table
+		seat    []uuid.UUID
+		want    []uuid.UUID
+		wantErr error
+	}{
+		{
+			name:  "unlimited",
+			table: Table{GuestIDs: []uuid.UUID{a}},
+			seat:  []uuid.UUID{b, c},
+			want:  []uuid.UUID{a, b, c},
+		},
+		{
+			name:  "fits exactly",
+			table: Table{Capacity: 3, GuestIDs: []uuid.UUID{a}},
+			seat:  []uuid.UUID{b, c},
+			want:  []uuid.UUID{a, b, c},
+		},
+		{
+			name:  "already seated",
+			table: Table{Capacity: 2, GuestIDs: []uuid.UUID{a, b}},
+			seat:  []uuid.UUID{b, b},
+			want:  []uuid.UUID{a, b},
+		},
+		{
+			name:    "full",
+			table:   Table{Capacity: 2, GuestIDs: []uuid.UUID{a}},
+			seat:    []uuid.UUID{b, c},
+			want:    []uuid.UUID{a},
+			wantErr: ErrTableFull,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.table.Seat(tc.seat...)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			if !slices.Equal(tc.table.GuestIDs, tc.want) {
+				t.Fatalf("got %v, want %v", tc.table.GuestIDs, tc.want)
+			}
+		})
+	}
+}
+
+func TestTable_Unseat(t *testing.T) {
+	a := uuid.MustParse("0eac703a-40f3-4318-ae96-f28e026a23c6")
+	b := uuid.MustParse("b5627acd-9332-476c-8466-f49de1567865")
+
+	table := Table{GuestIDs: []uuid.UUID{a, b}}
+	if table.Unseat(uuid.New()) {
+		t.Fatal("unseated a guest that was not seated")
+	}
+	if !table.Unseat(a) {
+		t.Fatal("guest was not unseated")
+	}
+	if !slices.Equal(table.GuestIDs, []uuid.UUID{b}) {
+		t.Fatalf("got %v, want %v", table.GuestIDs, []uuid.UUID{b})
+	}
+}
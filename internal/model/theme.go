@@ -0,0 +1,125 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidColor = errors.New("invalid color")
+	ErrInvalidFont  = errors.New("invalid font")
+	ErrInvalidCSS   = errors.New("invalid custom CSS")
+)
+
+// MaxThemeCSS is the maximum length of the custom CSS of a theme in bytes.
+const MaxThemeCSS = 16 << 10
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ThemeFonts are the font stacks a theme can choose from by name. They only
+// use fonts installed on the devices of the guests, since the pages do not
+// load resources from other hosts.
+var ThemeFonts = map[string]string{
+	"sans":    `ui-sans-serif, system-ui, sans-serif`,
+	"serif":   `ui-serif, Georgia, Cambria, "Times New Roman", serif`,
+	"rounded": `ui-rounded, "SF Pro Rounded", "Hiragino Maru Gothic ProN", "Arial Rounded MT Bold", sans-serif`,
+	"mono":    `ui-monospace, SFMono-Regular, Menlo, Consolas, monospace`,
+	"script":  `"Segoe Script", "Brush Script MT", "Apple Chancery", cursive`,
+}
+
+// ThemeFontNames lists the names of ThemeFonts in the order offered to the
+// admin.
+var ThemeFontNames = []string{"sans", "serif", "rounded", "mono", "script"}
+
+// Theme is the branding of an event on the invitations. Empty fields keep
+// the default look.
+type Theme struct {
+	// PrimaryColor is the background of the navigation and the banner, e.g.
+	// "#1f2937".
+	PrimaryColor string `json:"primary_color,omitempty" form:"primary_color"`
+	// AccentColor is the color of buttons and links.
+	AccentColor string `json:"accent_color,omitempty" form:"accent_color"`
+	// Font is the name of one of ThemeFonts.
+	Font string `json:"font,omitempty" form:"font"`
+	// BannerImage is the image shown on top of the invitations.
+	BannerImage *Image `json:"banner_image,omitempty" form:"-"`
+	// Banner is the banner as data URL, as it was stored before images were
+	// uploaded to the blob store. It is shown if there is no BannerImage.
+	Banner string `json:"banner,omitempty" form:"-"`
+	// CSS is appended to the styles of the invitations.
+	CSS string `json:"css,omitempty" form:"css"`
+}
+
+// IsZero reports whether the theme changes nothing.
+func (t *Theme) IsZero() bool {
+	return t == nil || *t == Theme{}
+}
+
+// FontFamily returns the font stack of the theme, or "" for the default.
+func (t *Theme) FontFamily() string {
+	return ThemeFonts[t.Font]
+}
+
+// Check validates the colors, the font and the custom CSS of the theme.
+func (t *Theme) Check() error {
+	for _, color := range []string{t.PrimaryColor, t.AccentColor} {
+		if color != "" && !hexColor.MatchString(color) {
+			return fmt.Errorf("%w %q, use e.g. #1f2937", ErrInvalidColor, color)
+		}
+	}
+	if _, ok := ThemeFonts[t.Font]; t.Font != "" && !ok {
+		return fmt.Errorf("%w %q", ErrInvalidFont, t.Font)
+	}
+	return checkCSS(t.CSS)
+}
+
+// checkCSS rejects custom CSS that is too long, imports other style sheets or
+// closes more blocks than it opens. The latter would break out of the block
+// the CSS is nested into for the preview.
+func checkCSS(css string) error {
+	if len(css) > MaxThemeCSS {
+		return fmt.Errorf("%w: longer than %d KB", ErrInvalidCSS, MaxThemeCSS>>10)
+	}
+	if strings.Contains(strings.ToLower(css), "@import") {
+		return fmt.Errorf("%w: @import is not allowed", ErrInvalidCSS)
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(css); i++ {
+		switch ch := css[i]; {
+		case ch == '\\':
+			i++
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return fmt.Errorf("%w: unterminated comment", ErrInvalidCSS)
+			}
+			i += end + 3
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("%w: unexpected }", ErrInvalidCSS)
+			}
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("%w: unterminated string", ErrInvalidCSS)
+	}
+	if depth != 0 {
+		return fmt.Errorf("%w: missing }", ErrInvalidCSS)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTheme_Check(t *testing.T) {
+	tt := []struct {
+		name    string
+		theme   Theme
+		wantErr error
+	}{
+		{name: "empty"},
+		{name: "colors", theme: Theme{PrimaryColor: "#1f2937", AccentColor: "#FFF"}},
+		{name: "color without hash", theme: Theme{PrimaryColor: "1f2937"}, wantErr: ErrInvalidColor},
+		{name: "named color", theme: Theme{AccentColor: "red"}, wantErr: ErrInvalidColor},
+		{name: "color with css", theme: Theme{AccentColor: "#fff;}body{"}, wantErr: ErrInvalidColor},
+		{name: "font", theme: Theme{Font: "serif"}},
+		{name: "unknown font", theme: Theme{Font: "Comic Sans"}, wantErr: ErrInvalidFont},
+		{name: "css", theme: Theme{CSS: "h1 { color: red; }\n@media print { nav { display: none } }"}},
+		{name: "css with braces in strings", theme: Theme{CSS: `a::after { content: "}"; } /* { */ b::before { content: '\'{' }`}},
+		{name: "css closing the block", theme: Theme{CSS: "} body { display: none"}, wantErr: ErrInvalidCSS},
+		{name: "css missing brace", theme: Theme{CSS: "h1 { color: red;"}, wantErr: ErrInvalidCSS},
+		{name: "css unterminated comment", theme: Theme{CSS: "h1 { } /* {"}, wantErr: ErrInvalidCSS},
+		{name: "css unterminated string", theme: Theme{CSS: `h1 { content: "}`}, wantErr: ErrInvalidCSS},
+		{name: "css import", theme: Theme{CSS: `@IMPORT url("https://example.com/a.css");`}, wantErr: ErrInvalidCSS},
+		{name: "css too long", theme: Theme{CSS: strings.Repeat("a", MaxThemeCSS+1)}, wantErr: ErrInvalidCSS},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.theme.Check(); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestTheme_IsZero(t *testing.T) {
+	var nilTheme *Theme
+	if !nilTheme.IsZero() {
+		t.Errorf("nil theme is not zero")
+	}
+	if !(&Theme{}).IsZero() {
+		t.Errorf("empty theme is not zero")
+	}
+	if (&Theme{Font: "serif"}).IsZero() {
+		t.Errorf("theme with font is zero")
+	}
+}
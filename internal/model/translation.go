@@ -3,6 +3,45 @@
 
 package model
 
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FallbackLanguage is the last language of every fallback chain and the
+// reference for the completeness of the other languages.
+const FallbackLanguage = "en"
+
+// ErrInvalidLanguage is returned for language keys that are no language tag
+// like "de" or "de-AT".
+var ErrInvalidLanguage = errors.New("invalid language")
+
+var languageTag = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// CheckLanguage returns ErrInvalidLanguage if lang can not be used as the key
+// of a translation.
+func CheckLanguage(lang string) error {
+	if !languageTag.MatchString(lang) {
+		return ErrInvalidLanguage
+	}
+	return nil
+}
+
+// Directions of the text of a translation.
+const (
+	DirectionLTR = "ltr"
+	DirectionRTL = "rtl"
+)
+
+// ErrInvalidDirection is returned for text directions other than "ltr" and
+// "rtl".
+var ErrInvalidDirection = errors.New("invalid text direction")
+
 type Translation struct {
 	Title          string                     `json:"title" form:"title"`
 	Greeting       string                     `json:"greeting" form:"greeting"`
@@ -12,11 +51,44 @@ type Translation struct {
 	Location       TranslationLocationSection `json:"location" form:"location"`
 	Hotels         TranslationHotelsSection   `json:"hotels" form:"hotels"`
 	Airports       TranslationAirportsSection `json:"airports" form:"airports"`
+	Registry       TranslationRegistrySection `json:"registry" form:"registry"`
 	Navigation     TranslationNavigation      `json:"navigation" form:"navigation"`
 	FlagImgSrc     string                     `json:"flag_img_src" form:"flag_img_src"`
 	Error          Error                      `json:"error" form:"error"`
 	Success        Success                    `json:"success" form:"success"`
 	And            string                     `json:"and" form:"and"`
+	AddToCalendar  string                     `json:"add_to_calendar" form:"add_to_calendar"`
+
+	// Locale is the BCP 47 tag of the language, e.g. "ar-EG". Empty means
+	// the key of the translation. Direction is the direction of the text,
+	// DirectionLTR or DirectionRTL, and empty means that of the locale.
+	// Neither is a text, so they are not translated, reported missing or
+	// taken from fallback languages.
+	Locale    string `json:"locale,omitempty" form:"locale" text:"-"`
+	Direction string `json:"direction,omitempty" form:"direction" text:"-"`
+}
+
+// Tag returns the locale of the translation of the given language.
+func (t *Translation) Tag(lang string) string {
+	if t.Locale != "" {
+		return t.Locale
+	}
+	return lang
+}
+
+// CheckSettings returns an error if the locale or the direction of the
+// translation are invalid.
+func (t *Translation) CheckSettings() error {
+	if t.Locale != "" {
+		if err := CheckLanguage(t.Locale); err != nil {
+			return fmt.Errorf("locale %q: %w", t.Locale, err)
+		}
+	}
+	switch t.Direction {
+	case "", DirectionLTR, DirectionRTL:
+		return nil
+	}
+	return fmt.Errorf("%q: %w", t.Direction, ErrInvalidDirection)
 }
 
 type TranslationGuestForm struct {
@@ -28,15 +100,41 @@ type TranslationGuestForm struct {
 	LabelAgeInput          string   `json:"label_age_input" form:"label_age_input"`
 	LabelButtonAddGuest    string   `json:"label_button_add_guest" form:"label_button_add_guest"`
 	LabelButtonSubmit      string   `json:"label_button_submit" form:"label_button_submit"`
+	LabelGuestLimit        string   `json:"label_guest_limit" form:"label_guest_limit"`
+	LabelDeadline          string   `json:"label_deadline" form:"label_deadline"`
+	LabelDietaryTags       string   `json:"label_dietary_tags" form:"label_dietary_tags"`
+	LabelAllergens         string   `json:"label_allergens" form:"label_allergens"`
+	LabelDietaryNote       string   `json:"label_dietary_note" form:"label_dietary_note"`
+	LabelYes               string   `json:"label_yes" form:"label_yes"`
+	LabelNo                string   `json:"label_no" form:"label_no"`
+	LabelSchedule          string   `json:"label_schedule" form:"label_schedule"`
+	LabelTravel            string   `json:"label_travel" form:"label_travel"`
+	LabelHotel             string   `json:"label_hotel" form:"label_hotel"`
+	LabelArrival           string   `json:"label_arrival" form:"label_arrival"`
+	LabelDeparture         string   `json:"label_departure" form:"label_departure"`
 	SelectOptionsAge       []string `json:"select_options_age" form:"select_options_age"`
 	SelectOptionsDiet      []string `json:"select_options_diet" form:"select_options_diet"`
 	SelectOptionsInvStatus []string `json:"select_options_inv_status" form:"select_options_inv_status"`
-	MessageSubmitSuccess   string   `json:"message_submit_success" form:"message_submit_success"`
+	// OptionsDietaryTags and OptionsAllergens map the dietary tags and
+	// allergens configured for the event to their labels.
+	OptionsDietaryTags   map[string]string `json:"options_dietary_tags,omitempty" form:"options_dietary_tags"`
+	OptionsAllergens     map[string]string `json:"options_allergens,omitempty" form:"options_allergens"`
+	MessageSubmitSuccess string            `json:"message_submit_success" form:"message_submit_success"`
+	MessageWaitlisted    string            `json:"message_waitlisted" form:"message_waitlisted"`
+	MessageReadOnly      string            `json:"message_read_only" form:"message_read_only"`
 }
 
 type TranslationLocationSection struct {
 	Title          string `json:"title" form:"title"`
 	OpenExternally string `json:"openExternally" form:"openExternally"`
+	// MapLabel describes the map for screen readers.
+	MapLabel      string `json:"map_label" form:"map_label"`
+	LegendVenue   string `json:"legend_venue" form:"legend_venue"`
+	LegendHotel   string `json:"legend_hotel" form:"legend_hotel"`
+	LegendAirport string `json:"legend_airport" form:"legend_airport"`
+	// OpenInApp is the label of the link opening a place in the maps app
+	// of the device.
+	OpenInApp string `json:"open_in_app" form:"open_in_app"`
 }
 
 type TranslationHotelsSection struct {
@@ -48,11 +146,23 @@ type TranslationAirportsSection struct {
 	Title string `json:"title" form:"title"`
 }
 
+type TranslationRegistrySection struct {
+	Title          string `json:"title" form:"title"`
+	Description    string `json:"description" form:"description"`
+	LabelPrice     string `json:"label_price" form:"label_price"`
+	LabelAvailable string `json:"label_available" form:"label_available"`
+	LabelClaimed   string `json:"label_claimed" form:"label_claimed"`
+	ButtonClaim    string `json:"button_claim" form:"button_claim"`
+	ButtonRelease  string `json:"button_release" form:"button_release"`
+	Link           string `json:"link" form:"link"`
+}
+
 type TranslationNavigation struct {
 	Guests   string `json:"guests" form:"guests"`
 	Map      string `json:"map" form:"map"`
 	Hotels   string `json:"hotels" form:"hotels"`
 	Airports string `json:"airports" form:"airports"`
+	Registry string `json:"registry" form:"registry"`
 }
 
 type LanguageOption struct {
@@ -61,11 +171,274 @@ type LanguageOption struct {
 }
 
 type Error struct {
-	Title    string `json:"title" form:"title"`
-	Process  string `json:"process" form:"process"`
-	Deadline string `json:"deadline" form:"deadline"`
+	Title           string `json:"title" form:"title"`
+	Process         string `json:"process" form:"process"`
+	Deadline        string `json:"deadline" form:"deadline"`
+	Policy          string `json:"policy" form:"policy"`
+	Answer          string `json:"answer" form:"answer"`
+	FullyBooked     string `json:"fully_booked" form:"fully_booked"`
+	GiftUnavailable string `json:"gift_unavailable" form:"gift_unavailable"`
+	Travel          string `json:"travel" form:"travel"`
 }
 
 type Success struct {
 	Title string `json:"title" form:"title"`
 }
+
+// ErrUnknownTextKey is returned for keys that do not belong to a text of a
+// translation.
+var ErrUnknownTextKey = errors.New("unknown text key")
+
+// maxListTexts limits the number of texts in a list, e.g. the age options.
+const maxListTexts = 100
+
+// SetText sets the text with the given key as returned by Texts. It returns
+// ErrUnknownTextKey if the translation has no such text. Lists and maps are
+// replaced by modified copies, so they may be shared with other
+// translations.
+func (t *Translation) SetText(key, text string) error {
+	if !setText(reflect.ValueOf(t).Elem(), strings.Split(key, "."), text) {
+		return fmt.Errorf("%q: %w", key, ErrUnknownTextKey)
+	}
+	return nil
+}
+
+func setText(v reflect.Value, path []string, text string) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		if len(path) == 0 {
+			return false
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if isText(v.Type().Field(i)) && jsonName(v.Type().Field(i)) == path[0] {
+				return setText(v.Field(i), path[1:], text)
+			}
+		}
+		return false
+	case reflect.Slice:
+		if len(path) != 1 {
+			return false
+		}
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= maxListTexts {
+			return false
+		}
+		n := max(v.Len(), i+1)
+		list := reflect.MakeSlice(v.Type(), n, n)
+		reflect.Copy(list, v)
+		list.Index(i).SetString(text)
+		v.Set(list)
+		return true
+	case reflect.Map:
+		if len(path) != 1 || path[0] == "" {
+			return false
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len()+1)
+		for _, k := range v.MapKeys() {
+			m.SetMapIndex(k, v.MapIndex(k))
+		}
+		m.SetMapIndex(reflect.ValueOf(path[0]), reflect.ValueOf(text))
+		v.Set(m)
+		return true
+	case reflect.String:
+		if len(path) != 0 {
+			return false
+		}
+		v.SetString(text)
+		return true
+	}
+	return false
+}
+
+// FallbackChain returns the languages whose texts are used for a language,
+// in order, e.g. "de-AT", "de" and "en".
+func FallbackChain(lang string) []string {
+	chain := []string{lang}
+	if base, _, ok := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-"); ok && base != "" {
+		chain = append(chain, base)
+	}
+	for _, l := range chain {
+		if strings.EqualFold(l, FallbackLanguage) {
+			return chain
+		}
+	}
+	return append(chain, FallbackLanguage)
+}
+
+// NegotiateLanguage returns the available language that fits an
+// Accept-Language header best, or "" if none does. The languages of the
+// header are tried by their weight, each first exactly and then by its base
+// language, e.g. "de-AT" matches "de". A base language also matches a
+// regional variant, e.g. "de" matches "de-CH", if nothing else does.
+func NegotiateLanguage(acceptLanguage string, available []string) string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = strings.TrimSpace(lang)
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if q <= 0 {
+			continue
+		}
+		ranges = append(ranges, weighted{lang: lang, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	find := func(lang string) string {
+		for _, a := range available {
+			if strings.EqualFold(a, lang) {
+				return a
+			}
+		}
+		return ""
+	}
+	for _, r := range ranges {
+		if a := find(r.lang); a != "" {
+			return a
+		}
+		if base, _, ok := strings.Cut(strings.ReplaceAll(r.lang, "_", "-"), "-"); ok {
+			if a := find(base); a != "" {
+				return a
+			}
+		}
+	}
+	for _, r := range ranges {
+		base, _, _ := strings.Cut(r.lang, "-")
+		for _, a := range available {
+			if availableBase, _, _ := strings.Cut(a, "-"); strings.EqualFold(availableBase, base) {
+				return a
+			}
+		}
+	}
+	return ""
+}
+
+// Texts returns the texts of the translation by their key, which is the
+// path of JSON names, e.g. "guest_form.label_yes" or
+// "guest_form.select_options_age.0".
+func (t *Translation) Texts() map[string]string {
+	res := make(map[string]string)
+	collectTexts("", reflect.ValueOf(t).Elem(), res)
+	return res
+}
+
+func collectTexts(key string, v reflect.Value, res map[string]string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name := jsonName(v.Type().Field(i))
+			if name == "-" || !isText(v.Type().Field(i)) {
+				continue
+			}
+			collectTexts(joinKey(key, name), v.Field(i), res)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectTexts(joinKey(key, strconv.Itoa(i)), v.Index(i), res)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			collectTexts(joinKey(key, k.String()), v.MapIndex(k), res)
+		}
+	case reflect.String:
+		res[key] = v.String()
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// isText reports whether a field contains texts. Settings of a translation
+// are tagged with text:"-".
+func isText(f reflect.StructField) bool {
+	return f.Tag.Get("text") != "-"
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// Missing returns the sorted keys of the texts which are set in the
+// reference translation but missing or empty in t.
+func (t *Translation) Missing(reference *Translation) []string {
+	texts := t.Texts()
+	var res []string
+	for key, text := range reference.Texts() {
+		if strings.TrimSpace(text) != "" && strings.TrimSpace(texts[key]) == "" {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// WithFallback returns a copy of the translation in which empty texts are
+// taken from fallback. Neither translation is modified.
+func (t *Translation) WithFallback(fallback *Translation) *Translation {
+	res := *t
+	fillEmpty(reflect.ValueOf(&res).Elem(), reflect.ValueOf(fallback).Elem())
+	return &res
+}
+
+// fillEmpty sets the empty strings of dst to the ones of src. Slices and maps
+// are replaced by merged copies, since dst shares them with the original.
+func fillEmpty(dst, src reflect.Value) {
+	switch dst.Kind() {
+	case reflect.Struct:
+		for i := 0; i < dst.NumField(); i++ {
+			if isText(dst.Type().Field(i)) {
+				fillEmpty(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		n := max(dst.Len(), src.Len())
+		if n == 0 {
+			return
+		}
+		merged := reflect.MakeSlice(dst.Type(), n, n)
+		reflect.Copy(merged, dst)
+		for i := 0; i < src.Len(); i++ {
+			fillEmpty(merged.Index(i), src.Index(i))
+		}
+		dst.Set(merged)
+	case reflect.Map:
+		if src.Len() == 0 {
+			return
+		}
+		merged := reflect.MakeMapWithSize(dst.Type(), dst.Len()+src.Len())
+		for _, k := range dst.MapKeys() {
+			merged.SetMapIndex(k, dst.MapIndex(k))
+		}
+		for _, k := range src.MapKeys() {
+			if v := merged.MapIndex(k); !v.IsValid() || v.String() == "" {
+				merged.SetMapIndex(k, src.MapIndex(k))
+			}
+		}
+		dst.Set(merged)
+	case reflect.String:
+		if dst.String() == "" {
+			dst.Set(src)
+		}
+	}
+}
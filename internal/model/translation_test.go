@@ -0,0 +1,241 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCheckLanguage(t *testing.T) {
+	tt := []struct {
+		lang    string
+		wantErr error
+	}{
+		{lang: "de"},
+		{lang: "de-AT"},
+		{lang: "zh-Hant-TW"},
+		{lang: "gsw"},
+		{lang: "", wantErr: ErrInvalidLanguage},
+		{lang: "d", wantErr: ErrInvalidLanguage},
+		{lang: "de.AT", wantErr: ErrInvalidLanguage},
+		{lang: "de_AT", wantErr: ErrInvalidLanguage},
+		{lang: "de-", wantErr: ErrInvalidLanguage},
+		{lang: "../en", wantErr: ErrInvalidLanguage},
+	}
+
+	for _, tc := range tt {
+		if err := CheckLanguage(tc.lang); !errors.Is(err, tc.wantErr) {
+			t.Errorf("CheckLanguage(%q) = %v, want %v", tc.lang, err, tc.wantErr)
+		}
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	tt := []struct {
+		lang string
+		want []string
+	}{
+		{lang: "de-AT", want: []string{"de-AT", "de", "en"}},
+		{lang: "pt_BR", want: []string{"pt_BR", "pt", "en"}},
+		{lang: "de", want: []string{"de", "en"}},
+		{lang: "en", want: []string{"en"}},
+		{lang: "en-GB", want: []string{"en-GB", "en"}},
+	}
+
+	for _, tc := range tt {
+		if got := FallbackChain(tc.lang); !slices.Equal(got, tc.want) {
+			t.Errorf("FallbackChain(%q) = %q, want %q", tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	available := []string{"de", "en", "fr-CA"}
+
+	tt := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "de", want: "de"},
+		{header: "DE-at,en;q=0.5", want: "de"},
+		{header: "it, en;q=0.8, de;q=0.9", want: "de"},
+		{header: "it, *;q=0.5", want: ""},
+		{header: "fr-FR, en;q=0.1", want: "en"},
+		{header: "fr", want: "fr-CA"},
+		{header: "de;q=0, en", want: "en"},
+		{header: "de;q=x, en", want: "en"},
+	}
+
+	for _, tc := range tt {
+		if got := NegotiateLanguage(tc.header, available); got != tc.want {
+			t.Errorf("NegotiateLanguage(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestTranslation_Missing(t *testing.T) {
+	reference := &Translation{
+		Title: "Party",
+		And:   "and",
+		GuestForm: TranslationGuestForm{
+			LabelYes:         "Yes",
+			SelectOptionsAge: []string{"Baby", "Teenager", "Adult"},
+			OptionsAllergens: map[string]string{"nuts": "Nuts"},
+		},
+	}
+
+	tt := []struct {
+		name        string
+		translation *Translation
+		want        []string
+	}{
+		{
+			name: "complete",
+			translation: &Translation{
+				Title:          "Feier",
+				And:            "und",
+				WelcomeMessage: "Hallo",
+				GuestForm: TranslationGuestForm{
+					LabelYes:         "Ja",
+					SelectOptionsAge: []string{"Baby", "Teenager", "Erwachsen"},
+					OptionsAllergens: map[string]string{"nuts": "Nüsse"},
+				},
+			},
+		},
+		{
+			name: "missing and empty",
+			translation: &Translation{
+				Title: " ",
+				GuestForm: TranslationGuestForm{
+					LabelYes:         "Ja",
+					SelectOptionsAge: []string{"Baby", ""},
+				},
+			},
+			want: []string{
+				"and",
+				"guest_form.options_allergens.nuts",
+				"guest_form.select_options_age.1",
+				"guest_form.select_options_age.2",
+				"title",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.translation.Missing(reference); !slices.Equal(got, tc.want) {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTranslation_WithFallback(t *testing.T) {
+	fallback := &Translation{
+		Title: "Party",
+		And:   "and",
+		GuestForm: TranslationGuestForm{
+			SelectOptionsAge: []string{"Baby", "Teenager", "Adult"},
+			OptionsAllergens: map[string]string{"nuts": "Nuts", "milk": "Milk"},
+		},
+		Locale:    "en-US",
+		Direction: DirectionLTR,
+	}
+	translation := &Translation{
+		Title: "Feier",
+		GuestForm: TranslationGuestForm{
+			SelectOptionsAge: []string{"Baby", ""},
+			OptionsAllergens: map[string]string{"nuts": "Nüsse"},
+		},
+	}
+
+	got := translation.WithFallback(fallback)
+	if got.Title != "Feier" || got.And != "and" {
+		t.Errorf("got title %q and %q, want %q and %q", got.Title, got.And, "Feier", "and")
+	}
+	if want := []string{"Baby", "Teenager", "Adult"}; !slices.Equal(got.GuestForm.SelectOptionsAge, want) {
+		t.Errorf("got age options %q, want %q", got.GuestForm.SelectOptionsAge, want)
+	}
+	if got.GuestForm.OptionsAllergens["nuts"] != "Nüsse" || got.GuestForm.OptionsAllergens["milk"] != "Milk" {
+		t.Errorf("got allergens %v", got.GuestForm.OptionsAllergens)
+	}
+
+	if got.Locale != "" || got.Direction != "" {
+		t.Errorf("got locale %q and direction %q from the fallback", got.Locale, got.Direction)
+	}
+
+	if translation.And != "" || len(translation.GuestForm.OptionsAllergens) != 1 || translation.GuestForm.SelectOptionsAge[1] != "" {
+		t.Errorf("translation was modified: %+v", translation)
+	}
+}
+
+func TestTranslation_SetText(t *testing.T) {
+	tt := []struct {
+		key     string
+		wantErr error
+		get     func(*Translation) string
+	}{
+		{key: "title", get: func(t *Translation) string { return t.Title }},
+		{key: "guest_form.label_yes", get: func(t *Translation) string { return t.GuestForm.LabelYes }},
+		{key: "guest_form.select_options_age.3", get: func(t *Translation) string { return t.GuestForm.SelectOptionsAge[3] }},
+		{key: "guest_form.options_allergens.nuts", get: func(t *Translation) string { return t.GuestForm.OptionsAllergens["nuts"] }},
+		{key: "unknown", wantErr: ErrUnknownTextKey},
+		{key: "guest_form", wantErr: ErrUnknownTextKey},
+		{key: "title.extra", wantErr: ErrUnknownTextKey},
+		{key: "guest_form.select_options_age.x", wantErr: ErrUnknownTextKey},
+		{key: "guest_form.select_options_age.1000", wantErr: ErrUnknownTextKey},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.key, func(t *testing.T) {
+			shared := []string{"Baby"}
+			translation := &Translation{GuestForm: TranslationGuestForm{SelectOptionsAge: shared}}
+			err := translation.SetText(tc.key, "text")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := tc.get(translation); got != "text" {
+				t.Fatalf("got %q, want %q", got, "text")
+			}
+			if translation.Texts()[tc.key] != "text" {
+				t.Fatalf("key %q is not returned by Texts", tc.key)
+			}
+			if shared[0] != "Baby" {
+				t.Fatalf("shared list was modified")
+			}
+		})
+	}
+}
+
+func TestTranslation_CheckSettings(t *testing.T) {
+	tt := []struct {
+		translation Translation
+		wantErr     error
+	}{
+		{translation: Translation{}},
+		{translation: Translation{Locale: "ar-EG", Direction: DirectionRTL}},
+		{translation: Translation{Locale: "ar EG"}, wantErr: ErrInvalidLanguage},
+		{translation: Translation{Direction: "up"}, wantErr: ErrInvalidDirection},
+	}
+
+	for _, tc := range tt {
+		if err := tc.translation.CheckSettings(); !errors.Is(err, tc.wantErr) {
+			t.Errorf("CheckSettings(%q, %q) = %v, want %v", tc.translation.Locale, tc.translation.Direction, err, tc.wantErr)
+		}
+	}
+
+	texts := (&Translation{Locale: "ar-EG"}).Texts()
+	if _, ok := texts["locale"]; ok {
+		t.Errorf("locale is listed as text")
+	}
+	if err := new(Translation).SetText("direction", DirectionRTL); !errors.Is(err, ErrUnknownTextKey) {
+		t.Errorf("SetText(direction) = %v, want %v", err, ErrUnknownTextKey)
+	}
+}
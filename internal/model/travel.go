@@ -0,0 +1,66 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidTravel = errors.New("departure is before arrival")
+
+// Travel describes how a guest arrives at and leaves the event. The hotel and
+// the airports refer to the locations of the event by ID.
+type Travel struct {
+	Hotel            string     `json:"hotel,omitempty" form:"hotel"`
+	ArrivalAirport   string     `json:"arrival_airport,omitempty" form:"arrival_airport"`
+	ArrivalAt        *time.Time `json:"arrival_at,omitempty" form:"-"`
+	DepartureAirport string     `json:"departure_airport,omitempty" form:"departure_airport"`
+	DepartureAt      *time.Time `json:"departure_at,omitempty" form:"-"`
+}
+
+// IsZero reports whether the guest did not state any travel plans.
+func (t Travel) IsZero() bool {
+	return t == Travel{}
+}
+
+// Hotel returns the hotel with the given ID, or nil if there is none.
+func (e *Event) Hotel(id string) *Location {
+	return findLocation(e.Hotels, id)
+}
+
+// Airport returns the airport with the given ID, or nil if there is none.
+func (e *Event) Airport(id string) *Location {
+	return findLocation(e.Airports, id)
+}
+
+// CheckTravel drops references to hotels and airports the event does not
+// offer and makes sure the guest does not leave before arriving.
+func (e *Event) CheckTravel(t Travel) (Travel, error) {
+	if e.Hotel(t.Hotel) == nil {
+		t.Hotel = ""
+	}
+	if e.Airport(t.ArrivalAirport) == nil {
+		t.ArrivalAirport = ""
+	}
+	if e.Airport(t.DepartureAirport) == nil {
+		t.DepartureAirport = ""
+	}
+	if t.ArrivalAt != nil && t.DepartureAt != nil && t.DepartureAt.Before(*t.ArrivalAt) {
+		return t, ErrInvalidTravel
+	}
+	return t, nil
+}
+
+func findLocation(locations []*Location, id string) *Location {
+	if id == "" {
+		return nil
+	}
+	for _, l := range locations {
+		if l.ID.String() == id {
+			return l
+		}
+	}
+	return nil
+}
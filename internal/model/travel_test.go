@@ -0,0 +1,76 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEvent_CheckTravel(t *testing.T) {
+	hotel := &Location{ID: uuid.MustParse("0eac703a-40f3-4318-ae96-f28e026a23c6")}
+	airport := &Location{ID: uuid.MustParse("b5627acd-9332-476c-8466-f49de1567865")}
+	event := Event{Hotels: []*Location{hotel}, Airports: []*Location{airport}}
+
+	arrival := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	departure := arrival.Add(48 * time.Hour)
+
+	tt := []struct {
+		name    string
+		travel  Travel
+		want    Travel
+		wantErr error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "known locations",
+			travel: Travel{
+				Hotel:            hotel.ID.String(),
+				ArrivalAirport:   airport.ID.String(),
+				DepartureAirport: airport.ID.String(),
+			},
+			want: Travel{
+				Hotel:            hotel.ID.String(),
+				ArrivalAirport:   airport.ID.String(),
+				DepartureAirport: airport.ID.String(),
+			},
+		},
+		{
+			name: "unknown locations",
+			travel: Travel{
+				Hotel:            airport.ID.String(),
+				ArrivalAirport:   hotel.ID.String(),
+				DepartureAirport: "somewhere",
+			},
+		},
+		{
+			name:   "departure after arrival",
+			travel: Travel{ArrivalAt: &arrival, DepartureAt: &departure},
+			want:   Travel{ArrivalAt: &arrival, DepartureAt: &departure},
+		},
+		{
+			name:    "departure before arrival",
+			travel:  Travel{ArrivalAt: &departure, DepartureAt: &arrival},
+			want:    Travel{ArrivalAt: &departure, DepartureAt: &arrival},
+			wantErr: ErrInvalidTravel,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := event.CheckTravel(tc.travel)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -64,6 +64,19 @@ func Unmarshal(input url.Values, target any) error {
 			}
 			fieldVal.SetFloat(fValue)
 		case reflect.Slice:
+			if field.Type.Elem().Kind() == reflect.String {
+				// NOTE: a list of strings is replaced as a whole, as long as
+				// the field is part of the input.
+				if _, ok := input[fieldName]; !ok {
+					continue
+				}
+				list := reflect.MakeSlice(field.Type, 0, len(value))
+				for _, item := range value {
+					list = reflect.Append(list, reflect.ValueOf(item).Convert(field.Type.Elem()))
+				}
+				fieldVal.Set(list)
+				continue
+			}
 			sliceValue := reflect.ValueOf(value)
 			for i := 0; i < sliceValue.Len(); i++ {
 				if isPrimitiveType(sliceValue.Type().Elem().Kind()) {
@@ -81,6 +94,43 @@ func Unmarshal(input url.Values, target any) error {
 					return err
 				}
 			}
+		case reflect.Map:
+			// NOTE: maps are keyed by the remainder of the field name, e.g.
+			// "labels.en", and hold either the first or all values.
+			elem := field.Type.Elem()
+			multi := elem.Kind() == reflect.Slice && elem.Elem().Kind() == reflect.String
+			if field.Type.Key().Kind() != reflect.String ||
+				(elem.Kind() != reflect.String && elem.Kind() != reflect.Int && !multi) {
+				panic(fmt.Sprintf("unsupported type: %s", field.Type.String()))
+			}
+			prefix := fmt.Sprintf("%s.", fieldName)
+			entries := reflect.MakeMap(field.Type)
+			for k, v := range input {
+				key, ok := strings.CutPrefix(k, prefix)
+				if !ok || key == "" || len(v) == 0 {
+					continue
+				}
+				var entry reflect.Value
+				switch {
+				case multi:
+					entry = reflect.ValueOf(v).Convert(elem)
+				case elem.Kind() == reflect.Int:
+					if v[0] == "" {
+						continue
+					}
+					intValue, err := strconv.Atoi(v[0])
+					if err != nil {
+						return err
+					}
+					entry = reflect.ValueOf(intValue).Convert(elem)
+				default:
+					entry = reflect.ValueOf(v[0]).Convert(elem)
+				}
+				entries.SetMapIndex(reflect.ValueOf(key).Convert(field.Type.Key()), entry)
+			}
+			if entries.Len() > 0 {
+				fieldVal.Set(entries)
+			}
 		case reflect.Struct:
 			newInput := make(url.Values, len(input))
 			for k, v := range input {
@@ -0,0 +1,148 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package form
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	City string `form:"city"`
+	Zip  int    `form:"zip"`
+}
+
+type testTarget struct {
+	Name     string              `form:"name"`
+	Count    int                 `form:"count"`
+	Ratio    float64             `form:"ratio"`
+	Active   bool                `form:"active"`
+	Tags     []string            `form:"tags"`
+	Labels   map[string]string   `form:"labels"`
+	Amounts  map[string]int      `form:"amounts"`
+	Choices  map[string][]string `form:"choices"`
+	Address  testAddress         `form:"address"`
+	Ignored  string              `form:"-"`
+	Untagged string
+}
+
+func TestUnmarshal(t *testing.T) {
+	initial := func() testTarget {
+		return testTarget{
+			Name:     "before",
+			Count:    3,
+			Ratio:    0.5,
+			Tags:     []string{"old"},
+			Address:  testAddress{City: "Berlin", Zip: 10115},
+			Ignored:  "ignored",
+			Untagged: "untagged",
+		}
+	}
+
+	tt := []struct {
+		name  string
+		input url.Values
+		want  func(*testTarget)
+	}{
+		{
+			name:  "fields",
+			input: url.Values{"name": {"after"}, "count": {"7"}, "ratio": {"1.25"}, "active": {"TRUE"}, "address.city": {"Berlin"}},
+			want: func(w *testTarget) {
+				w.Name, w.Count, w.Ratio, w.Active = "after", 7, 1.25, true
+			},
+		},
+		{
+			name:  "missing fields",
+			input: url.Values{},
+			want: func(w *testTarget) {
+				// NOTE: strings are reset, numbers and lists are kept.
+				w.Name, w.Address.City = "", ""
+			},
+		},
+		{
+			name:  "empty numbers are skipped",
+			input: url.Values{"name": {"before"}, "count": {""}, "ratio": {""}, "address.city": {"Berlin"}, "address.zip": {""}},
+			want:  func(w *testTarget) {},
+		},
+		{
+			name:  "repeated keys",
+			input: url.Values{"name": {"first", "second"}, "count": {"1", "2"}, "tags": {"a", "b", "a"}},
+			want: func(w *testTarget) {
+				w.Name, w.Count, w.Tags = "first", 1, []string{"a", "b", "a"}
+				w.Address.City = ""
+			},
+		},
+		{
+			name:  "empty slice",
+			input: url.Values{"name": {"before"}, "tags": {}, "address.city": {"Berlin"}},
+			want: func(w *testTarget) {
+				w.Tags = []string{}
+			},
+		},
+		{
+			name: "map keys with brackets",
+			input: url.Values{
+				"name":               {"before"},
+				"address.city":       {"Berlin"},
+				"labels.[en]":        {"Hello"},
+				"labels.de[formal]":  {"Guten Tag"},
+				"labels.":            {"no key"},
+				"amounts.items[0]":   {"2"},
+				"amounts.items[1]":   {""},
+				"choices.q[1].multi": {"x", "y"},
+			},
+			want: func(w *testTarget) {
+				w.Labels = map[string]string{"[en]": "Hello", "de[formal]": "Guten Tag"}
+				w.Amounts = map[string]int{"items[0]": 2}
+				w.Choices = map[string][]string{"q[1].multi": {"x", "y"}}
+			},
+		},
+		{
+			name:  "nested struct",
+			input: url.Values{"name": {"before"}, "address.city": {"Hamburg"}, "address.zip": {"20095"}, "city": {"Munich"}},
+			want: func(w *testTarget) {
+				w.Address = testAddress{City: "Hamburg", Zip: 20095}
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, want := initial(), initial()
+			tc.want(&want)
+			if err := Unmarshal(tc.input, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	var target testTarget
+	for name, input := range map[string]url.Values{
+		"invalid int":        {"count": {"many"}},
+		"invalid float":      {"ratio": {"half"}},
+		"invalid nested int": {"address.zip": {"zip"}},
+		"invalid map int":    {"amounts.a": {"b"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := Unmarshal(input, &target); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	var invalid *InvalidUnmarshalError
+	if err := Unmarshal(url.Values{}, target); !errors.As(err, &invalid) {
+		t.Errorf("got error %v for a non-pointer", err)
+	}
+	if err := Unmarshal(url.Values{}, (*testTarget)(nil)); !errors.As(err, &invalid) {
+		t.Errorf("got error %v for a nil pointer", err)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package richtext sanitizes the restricted HTML admins may use in
+// translations.
+package richtext
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements are the elements kept by Sanitize and the attributes they
+// may have.
+var allowedElements = map[atom.Atom][]string{
+	atom.A:      {"href", "title"},
+	atom.B:      nil,
+	atom.Br:     nil,
+	atom.Em:     nil,
+	atom.I:      nil,
+	atom.Li:     nil,
+	atom.Ol:     nil,
+	atom.P:      nil,
+	atom.S:      nil,
+	atom.Strong: nil,
+	atom.U:      nil,
+	atom.Ul:     nil,
+}
+
+// droppedElements are removed including their content.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Template: true,
+	atom.Textarea: true,
+	atom.Title:    true,
+}
+
+// allowedSchemes are the schemes of absolute links. Relative links are
+// allowed as well.
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+	"tel":    true,
+}
+
+// Sanitize removes all elements and attributes from s which are not
+// allowed in rich text, and closes the elements left open. Text, including
+// template actions like {{ firstnames }}, is kept as written except that
+// angle brackets are escaped.
+func Sanitize(s string) string {
+	var (
+		b     strings.Builder
+		open  []atom.Atom
+		depth int // of dropped elements
+	)
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		// Raw must be read first, since Token unescapes the text in place.
+		raw := string(z.Raw())
+		tok := z.Token()
+		switch tt {
+		case nethtml.TextToken:
+			if depth > 0 {
+				continue
+			}
+			raw = strings.ReplaceAll(raw, "<", "&lt;")
+			raw = strings.ReplaceAll(raw, ">", "&gt;")
+			b.WriteString(raw)
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if droppedElements[tok.DataAtom] {
+				if tt == nethtml.StartTagToken {
+					depth++
+				}
+				continue
+			}
+			attrs, ok := allowedElements[tok.DataAtom]
+			if !ok || depth > 0 {
+				continue
+			}
+			writeStartTag(&b, tok, attrs)
+			if tt == nethtml.StartTagToken && tok.DataAtom != atom.Br {
+				open = append(open, tok.DataAtom)
+			}
+		case nethtml.EndTagToken:
+			if droppedElements[tok.DataAtom] {
+				if depth > 0 {
+					depth--
+				}
+				continue
+			}
+			if depth > 0 {
+				continue
+			}
+			// Close the element and all elements opened within it.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.DataAtom {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j].String() + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i].String() + ">")
+	}
+	return b.String()
+}
+
+func writeStartTag(b *strings.Builder, tok nethtml.Token, allowed []string) {
+	b.WriteString("<" + tok.DataAtom.String())
+	for _, attr := range tok.Attr {
+		if attr.Namespace != "" || !contains(allowed, attr.Key) {
+			continue
+		}
+		if attr.Key == "href" && !safeURL(attr.Val) {
+			continue
+		}
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if tok.DataAtom == atom.A {
+		b.WriteString(` rel="noopener noreferrer"`)
+	}
+	b.WriteString(">")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// safeURL reports whether a link is relative or uses an allowed scheme.
+func safeURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || allowedSchemes[strings.ToLower(u.Scheme)]
+}
+
+// HTML sanitizes s and marks the result as safe for html/template.
+func HTML(s string) template.HTML {
+	return template.HTML(Sanitize(s))
+}
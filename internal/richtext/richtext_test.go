@@ -0,0 +1,73 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package richtext
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tt := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain text",
+			in:   "Welcome to our website.",
+			want: "Welcome to our website.",
+		},
+		{
+			name: "allowed markup",
+			in:   "<b>Welcome</b><br />to <em>our</em> party",
+			want: "<b>Welcome</b><br>to <em>our</em> party",
+		},
+		{
+			name: "template actions",
+			in:   `Hello {{ firstnames }}, {{ plural guestcount "you" "you all" }} & co`,
+			want: `Hello {{ firstnames }}, {{ plural guestcount "you" "you all" }} & co`,
+		},
+		{
+			name: "script",
+			in:   `Hi<script>alert("x")</script>!`,
+			want: "Hi!",
+		},
+		{
+			name: "event handler",
+			in:   `<b onclick="alert(1)">Hi</b><img src=x onerror="alert(1)">`,
+			want: "<b>Hi</b>",
+		},
+		{
+			name: "safe link",
+			in:   `<a href="https://example.com/?a=1&b=2" target="_blank">Map</a>`,
+			want: `<a href="https://example.com/?a=1&amp;b=2" rel="noopener noreferrer">Map</a>`,
+		},
+		{
+			name: "javascript link",
+			in:   `<a href=" JavaScript:alert(1)">Map</a>`,
+			want: `<a rel="noopener noreferrer">Map</a>`,
+		},
+		{
+			name: "unbalanced",
+			in:   "<b><i>bold</b> text</i><ul><li>one",
+			want: "<b><i>bold</i></b> text<ul><li>one</li></ul>",
+		},
+		{
+			name: "nested brackets",
+			in:   "<<script>script>alert(1)<</script>/script>",
+			want: "&lt;/script&gt;",
+		},
+		{
+			name: "entities",
+			in:   "&lt;script&gt; 1 < 2",
+			want: "&lt;script&gt; 1 &lt; 2",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Sanitize(tc.in); got != tc.want {
+				t.Fatalf("Sanitize(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
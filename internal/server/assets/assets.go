@@ -0,0 +1,112 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package assets serves static files under names that contain a hash of
+// their content, e.g. "js/app.3f2a9c1e04b7.js". Browsers can cache such files
+// forever, since a changed file gets a new name.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ErrMissing is returned for static files that do not exist.
+var ErrMissing = errors.New("missing static file")
+
+// hashLength is the number of hex digits of the content hash in file names.
+const hashLength = 12
+
+// immutable is the Cache-Control header of files requested by their hashed
+// name.
+const immutable = "public, max-age=31536000, immutable"
+
+// Manifest maps the static files to their hashed names.
+type Manifest struct {
+	prefix string
+	fsys   fs.FS
+	hashed map[string]string
+	files  map[string]string
+}
+
+// New hashes all files of fsys. Their URLs start with prefix, e.g. "/static".
+func New(fsys fs.FS, prefix string) (*Manifest, error) {
+	m := &Manifest{
+		prefix: strings.TrimSuffix(prefix, "/"),
+		fsys:   fsys,
+		hashed: make(map[string]string),
+		files:  make(map[string]string),
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hashed := hashedName(name, hex.EncodeToString(sum[:])[:hashLength])
+		m.hashed[name] = hashed
+		m.files[hashed] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// hashedName inserts the hash before the extension of a file name, e.g.
+// "js/htmx.min.js" becomes "js/htmx.min.<hash>.js".
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// Path returns the URL of a static file by its hashed name, or ErrMissing if
+// there is no such file.
+func (m *Manifest) Path(name string) (string, error) {
+	hashed, ok := m.hashed[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrMissing, name)
+	}
+	return m.prefix + "/" + hashed, nil
+}
+
+// Check returns ErrMissing for every name without a static file, e.g. if the
+// vendored files were not fetched with "make assets".
+func (m *Manifest) Check(names ...string) error {
+	var errs []error
+	for _, name := range names {
+		if _, err := m.Path(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ServeHTTP serves the static files below the prefix, by their hashed name
+// as well as their original one. Only files requested by their hashed name
+// are cached by browsers without asking again.
+func (m *Manifest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, m.prefix), "/")
+	if file, ok := m.files[name]; ok {
+		w.Header().Set("Cache-Control", immutable)
+		name = file
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	// NOTE: directories are not listed.
+	if info, err := fs.Stat(m.fsys, name); err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFileFS(w, r, m.fsys, name)
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package assets
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+)
+
+func TestManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		"js/app.js":   {Data: []byte("console.log(1)")},
+		"robots.txt":  {Data: []byte("User-agent: *")},
+		"css/app.css": {Data: []byte("body{}")},
+	}
+	m, err := New(fsys, "/static/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	appJS, err := m.Path("js/app.js")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !regexp.MustCompile(`^/static/js/app\.[0-9a-f]{12}\.js$`).MatchString(appJS) {
+		t.Fatalf("got path %q", appJS)
+	}
+	if got, _ := m.Path("/js/app.js"); got != appJS {
+		t.Errorf("got path %q for leading slash, want %q", got, appJS)
+	}
+	if _, err := m.Path("missing.js"); !errors.Is(err, ErrMissing) {
+		t.Errorf("got error %v for missing file, want %v", err, ErrMissing)
+	}
+	if err := m.Check("js/app.js", "css/app.css"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := m.Check("js/app.js", "vendor/htmx.min.js"); !errors.Is(err, ErrMissing) {
+		t.Errorf("got error %v for missing file, want %v", err, ErrMissing)
+	}
+
+	changed, err := New(fstest.MapFS{"js/app.js": {Data: []byte("console.log(2)")}}, "/static")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := changed.Path("js/app.js"); got == appJS {
+		t.Errorf("path did not change with the content")
+	}
+
+	tt := []struct {
+		path         string
+		wantStatus   int
+		wantBody     string
+		wantCacheCtl string
+	}{
+		{path: appJS, wantStatus: http.StatusOK, wantBody: "console.log(1)", wantCacheCtl: immutable},
+		{path: "/static/robots.txt", wantStatus: http.StatusOK, wantBody: "User-agent: *", wantCacheCtl: "no-cache"},
+		{path: "/static/js/", wantStatus: http.StatusNotFound},
+		{path: "/static/", wantStatus: http.StatusNotFound},
+		{path: "/static/js/app.000000000000.js", wantStatus: http.StatusNotFound},
+		{path: "/static/../assets.go", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if w.Code != tc.wantStatus {
+				t.Fatalf("got status %d, want %d", w.Code, tc.wantStatus)
+			}
+			if tc.wantBody != "" && w.Body.String() != tc.wantBody {
+				t.Errorf("got body %q, want %q", w.Body.String(), tc.wantBody)
+			}
+			if tc.wantCacheCtl != "" && w.Header().Get("Cache-Control") != tc.wantCacheCtl {
+				t.Errorf("got Cache-Control %q, want %q", w.Header().Get("Cache-Control"), tc.wantCacheCtl)
+			}
+		})
+	}
+}
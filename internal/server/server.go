@@ -19,14 +19,21 @@ import (
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/quixsi/core/internal/blob"
 	"github.com/quixsi/core/internal/db"
+	"github.com/quixsi/core/internal/geocode"
 	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/server/assets"
 	"github.com/quixsi/core/internal/server/templates"
 )
 
 //go:embed all:static
 var staticFS embed.FS
 
+// NewServer builds the routes and handlers of the party. They are built
+// once, as the handlers serialize concurrent changes with their mutexes. It
+// fails if a static file the pages refer to is missing, e.g. because "make
+// assets" was not run before the build.
 func NewServer(
 	serviceName string,
 	staticDir string,
@@ -35,8 +42,11 @@ func NewServer(
 	gStore db.GuestStore,
 	tStore db.TranslationStore,
 	eStore db.EventStore,
-) *Server {
-	return &Server{
+	sStore db.TableStore,
+	bStore blob.Store,
+	geocoder geocode.Provider,
+) (*Server, error) {
+	s := &Server{
 		logger:      slog.Default().WithGroup("http"),
 		serviceName: serviceName,
 		staticDir:   staticDir,
@@ -45,7 +55,16 @@ func NewServer(
 		gStore:      gStore,
 		tStore:      tStore,
 		eStore:      eStore,
+		sStore:      sStore,
+		bStore:      bStore,
+		geocoder:    geocoder,
 	}
+	static, err := s.staticAssets()
+	if err != nil {
+		return nil, err
+	}
+	s.mux = s.routes(static)
+	return s, nil
 }
 
 type Server struct {
@@ -57,13 +76,53 @@ type Server struct {
 	gStore      db.GuestStore
 	tStore      db.TranslationStore
 	eStore      db.EventStore
+	sStore      db.TableStore
+	bStore      blob.Store
+	geocoder    geocode.Provider
+
+	mux *gin.Engine
+}
+
+// staticAssets returns the static files, either from the static directory
+// or the embedded ones. They are hashed once, so changes of the static
+// directory require a restart. It fails if a file the pages refer to is
+// missing.
+func (s *Server) staticAssets() (*assets.Manifest, error) {
+	var staticDir fs.FS
+	switch {
+	case s.staticDir != "":
+		staticDir = os.DirFS(s.staticDir)
+	default:
+		var err error
+		staticDir, err = fs.Sub(staticFS, "static")
+		if err != nil {
+			return nil, err
+		}
+	}
+	static, err := assets.New(staticDir, "/static")
+	if err != nil {
+		return nil, err
+	}
+	names, err := templates.RequiredAssets()
+	if err != nil {
+		return nil, err
+	}
+	if err := static.Check(names...); err != nil {
+		return nil, err
+	}
+	return static, nil
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) routes(static *assets.Manifest) *gin.Engine {
 	mux := gin.New()
 	if os.Getenv("GIN_MODE") == "" {
 		gin.SetMode(gin.ReleaseMode)
 	}
+	mux.Use(securityHeaders)
 
 	middlewares := []gin.HandlerFunc{
 		sloggin.NewWithConfig(s.logger,
@@ -91,46 +150,80 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		username: password,
 	}))...)
 
-	var staticDir fs.FS
-	var err error
-	switch {
-	case s.staticDir != "":
-		staticDir = os.DirFS(s.staticDir)
-	default:
-		staticDir, err = fs.Sub(staticFS, "static")
-		if err != nil {
-			panic(err)
-		}
-	}
+	mux.GET("/static/*filepath", gin.WrapH(static))
+	mux.HEAD("/static/*filepath", gin.WrapH(static))
 
-	mux.StaticFS("/static", http.FS(fs.FS(staticDir)))
+	guestHandler := templates.NewGuestHandler(s.iStore, s.tStore, s.gStore, s.eStore, s.sStore, s.bStore, s.geocoder, static)
+	public := mux.Group("/", middlewares...)
+	public.GET("/theme.css", guestHandler.ThemeCSS)
+	public.GET("/theme/banner", guestHandler.ThemeBanner)
+	public.GET("/media/*key", guestHandler.ServeMedia)
+	public.HEAD("/media/*key", guestHandler.ServeMedia)
 
-	if !s.deadline.IsZero() {
-		mux.Use(append(middlewares, readOnly(s.logger, s.deadline, s.tStore))...)
-	}
+	// NOTE: the admin area, static files, theme and media are registered
+	// before, so they are not affected by the deadline.
+	mux.Use(append(middlewares, defaultLanguage(s.iStore), readOnly(s.logger, s.deadline, s.iStore, s.eStore, s.tStore))...)
 
-	mux.Use(append(middlewares, inviteExists(s.iStore))...)
-	guestHandler := templates.NewGuestHandler(s.iStore, s.tStore, s.gStore, s.eStore)
+	mux.Use(inviteExists(s.iStore))
 	mux.GET("/:uuid", guestHandler.RenderForm)
+	mux.GET("/:uuid/event.ics", guestHandler.ExportCalendar)
 	mux.PUT("/:uuid/guests", guestHandler.Create)
 	mux.DELETE("/:uuid/guests/:guestid", guestHandler.Delete)
 	mux.POST("/:uuid/submit", guestHandler.Submit)
+	mux.POST("/:uuid/gifts/:giftid", guestHandler.ClaimGift)
+	mux.DELETE("/:uuid/gifts/:giftid", guestHandler.ReleaseGift)
 
 	adminArea.GET("/", guestHandler.RenderAdminOverview)
 	adminArea.POST("/invitation", guestHandler.CreateInvitation)
+	adminArea.POST("/invitation/:uuid", guestHandler.UpdateInvitation)
+	adminArea.POST("/guests/:uuid/status", guestHandler.UpdateGuestStatus)
+	adminArea.GET("/guests.csv", guestHandler.ExportGuests)
+	adminArea.GET("/travel", guestHandler.RenderTravel)
+	adminArea.GET("/travel.csv", guestHandler.ExportTravel)
+	adminArea.GET("/event.ics", guestHandler.ExportAdminCalendar)
 
 	adminArea.POST("/event", guestHandler.UpdateEvent)
 	adminArea.POST("/event/airports", guestHandler.CreateAirport)
 	adminArea.DELETE("/event/airports/:uuid", guestHandler.DeleteAirport)
 	adminArea.POST("/event/hotels", guestHandler.CreateHotel)
 	adminArea.DELETE("/event/hotels/:uuid", guestHandler.DeleteHotel)
+	adminArea.POST("/event/questions", guestHandler.CreateQuestion)
+	adminArea.PUT("/event/questions", guestHandler.UpdateQuestions)
+	adminArea.DELETE("/event/questions/:uuid", guestHandler.DeleteQuestion)
+	adminArea.POST("/event/sub-events", guestHandler.CreateSubEvent)
+	adminArea.PUT("/event/sub-events", guestHandler.UpdateSubEvents)
+	adminArea.DELETE("/event/sub-events/:uuid", guestHandler.DeleteSubEvent)
+	adminArea.POST("/event/gifts", guestHandler.CreateGift)
+	adminArea.PUT("/event/gifts", guestHandler.UpdateGifts)
+	adminArea.DELETE("/event/gifts/:uuid", guestHandler.DeleteGift)
+	adminArea.POST("/locations/:uuid/photos", guestHandler.UploadPhoto)
+	adminArea.DELETE("/locations/:uuid/photos/:photoid", guestHandler.DeletePhoto)
+	adminArea.POST("/theme", guestHandler.UpdateTheme)
+	adminArea.GET("/theme/preview", guestHandler.RenderThemePreview)
+	adminArea.GET("/theme/preview.css", guestHandler.ThemePreviewCSS)
 
-	translations := templates.NewTranslationHandler(s.tStore)
+	adminArea.POST("/tables", guestHandler.CreateTable)
+	adminArea.PUT("/tables", guestHandler.UpdateTables)
+	adminArea.DELETE("/tables/:uuid", guestHandler.DeleteTable)
+	adminArea.POST("/tables/:uuid/guests", guestHandler.SeatGuest)
+	adminArea.DELETE("/tables/:uuid/guests/:guestid", guestHandler.UnseatGuest)
+	adminArea.GET("/seating", guestHandler.RenderSeatingPrint)
+	adminArea.GET("/seating.csv", guestHandler.ExportSeating)
+	adminArea.GET("/seating-diet.csv", guestHandler.ExportSeatingDiet)
+
+	translations := templates.NewTranslationHandler(s.tStore, guestHandler)
 	adminArea.POST("/translations", translations.UpdateLanguage)
+	adminArea.DELETE("/translations/:lang", translations.DeleteLanguage)
+	adminArea.POST("/translations/:lang/rename", translations.RenameLanguage)
+	adminArea.POST("/translations/:lang/clone", translations.CloneLanguage)
+	adminArea.POST("/translations/:lang/flag", translations.UploadFlag)
+	adminArea.GET("/translations/export", translations.ExportTranslations)
+	adminArea.POST("/translations/import", translations.PreviewImport)
+	adminArea.POST("/translations/import/apply", translations.ApplyImport)
 
 	mux.NoRoute(notFound)
 
-	mux.ServeHTTP(w, r)
+	return mux
 }
 
 func inviteExists(db db.InvitationStore) gin.HandlerFunc {
@@ -148,10 +241,47 @@ func inviteExists(db db.InvitationStore) gin.HandlerFunc {
 	}
 }
 
+// defaultLanguage passes the default language of the invitation to the
+// handlers, which show it to guests that did not choose a language. It runs
+// before readOnly, so the deadline error is shown in that language, too.
+func defaultLanguage(iStore db.InvitationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("uuid"))
+		if err != nil {
+			c.Next()
+			return
+		}
+		if invite, err := iStore.GetInvitationByID(c.Request.Context(), id); err == nil {
+			c.Set(templates.DefaultLanguageKey, invite.DefaultLanguage)
+		}
+		c.Next()
+	}
+}
+
 func notFound(c *gin.Context) {
 	c.JSON(http.StatusNotFound, gin.H{"code": "PAGE_NOT_FOUND", "message": "Page not found"})
 }
 
+// contentSecurityPolicy only allows resources served by the party itself,
+// so pages work without internet access and guests are not tracked by third
+// parties. Images may also be data URLs, e.g. uploaded flags. The map of the
+// venue is drawn by the server, too, and only links to map providers.
+const contentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self'; " +
+	"style-src 'self'; " +
+	"img-src 'self' data:; " +
+	"object-src 'none'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'; " +
+	"frame-ancestors 'none'"
+
+func securityHeaders(c *gin.Context) {
+	c.Header("Content-Security-Policy", contentSecurityPolicy)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Referrer-Policy", "same-origin")
+	c.Next()
+}
+
 func slogAddTraceAttributes(c *gin.Context) {
 	sloggin.AddCustomAttributes(c,
 		slog.String("trace-id", trace.SpanFromContext(c.Request.Context()).SpanContext().TraceID().String()),
@@ -162,26 +292,56 @@ func slogAddTraceAttributes(c *gin.Context) {
 	c.Next()
 }
 
-func readOnly(logger *slog.Logger, deadline time.Time, tStore db.TranslationStore) gin.HandlerFunc {
+// readOnly rejects modifications of an invitation after its response
+// deadline. The deadline is taken from the event, or the given fallback if the
+// event has none, and can be extended per invitation.
+func readOnly(logger *slog.Logger, fallback time.Time, iStore db.InvitationStore, eStore db.EventStore, tStore db.TranslationStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var span trace.Span
 		ctx := c.Request.Context()
 		ctx, span = tracer.Start(ctx, "Middleware.readOnly")
 		defer span.End()
 
-		if deadline.Before(time.Now()) {
-
-			errorHandler := templates.NewErrorHandler(tStore)
-			if c.Request.Method != http.MethodGet {
-				err := errors.New("request method not allowed")
-				span.RecordError(err)
-				span.SetStatus(codes.Error, err.Error())
-				logger.ErrorContext(ctx, "readOnly-mode", "error", err)
-				errorHandler.Handle(c, model.ErrorReasonDeadline)
-				c.Status(http.StatusMethodNotAllowed)
-				c.Abort()
-			}
+		id, err := uuid.Parse(c.Param("uuid"))
+		if err != nil {
 			c.Next()
+			return
+		}
+		invite, err := iStore.GetInvitationByID(ctx, id)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		event, err := eStore.GetEvent(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			logger.ErrorContext(ctx, "could not find event", "error", err)
+			c.String(http.StatusInternalServerError, "could not find event")
+			c.Abort()
+			return
+		}
+
+		deadline := fallback
+		if event.Deadline != nil {
+			deadline = *event.Deadline
+		}
+		deadline = invite.ResponseDeadline(deadline)
+		if deadline.IsZero() {
+			c.Next()
+			return
+		}
+		c.Set(templates.DeadlineKey, deadline)
+
+		if deadline.Before(time.Now()) && c.Request.Method != http.MethodGet {
+			err := errors.New("request method not allowed")
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			logger.ErrorContext(ctx, "readOnly-mode", "error", err)
+			templates.NewErrorHandler(tStore).Handle(c, model.ErrorReasonDeadline)
+			c.Abort()
+			return
 		}
 		c.Next()
 	}
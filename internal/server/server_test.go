@@ -0,0 +1,494 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/quixsi/core/internal/blob"
+	"github.com/quixsi/core/internal/db/jsondb"
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/server/templates"
+)
+
+type testServer struct {
+	*Server
+	iStore *jsondb.InvitationStore
+	gStore *jsondb.GuestStore
+	eStore *jsondb.EventStore
+}
+
+// newTestServer returns a server with the JSON stores of the test data and
+// empty static files.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"event.json", "guests.json", "invitations.json", "translations.json"} {
+		data, err := os.ReadFile(filepath.Join("..", "..", "testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	staticDir := t.TempDir()
+	names, err := templates.RequiredAssets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		name = filepath.Join(staticDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	iStore, err := jsondb.NewInvitationStore(filepath.Join(dir, "invitations.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gStore, err := jsondb.NewGuestStore(filepath.Join(dir, "guests.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tStore, err := jsondb.NewTranslationStore(filepath.Join(dir, "translations.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eStore, err := jsondb.NewEventStore(filepath.Join(dir, "event.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sStore, err := jsondb.NewTableStore(filepath.Join(dir, "tables.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bStore, err := blob.NewFileStore(filepath.Join(dir, "media"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer("party-test", staticDir, time.Time{}, iStore, gStore, tStore, eStore, sStore, bStore, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &testServer{Server: s, iStore: iStore, gStore: gStore, eStore: eStore}
+}
+
+func TestSubmit_Capacity(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+
+	guests, err := s.gStore.ListGuests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accepted int
+	for _, g := range guests {
+		if g.InvitationStatus == model.InvitationStatusAccepted {
+			accepted++
+		}
+	}
+
+	const free, invitations = 3, 100
+	event, err := s.eStore.GetEvent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.Capacity = accepted + free
+	event.Deadline = nil
+	if err := s.eStore.UpdateEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	forms := make(map[string]url.Values, invitations)
+	for i := 0; i < invitations; i++ {
+		g := &model.Guest{Firstname: fmt.Sprintf("Guest %d", i), InvitationStatus: model.InvitationStatusNotAnswered}
+		if _, err := s.gStore.CreateGuest(ctx, g); err != nil {
+			t.Fatal(err)
+		}
+		inv, err := s.iStore.CreateInvitation(ctx, g.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		forms[inv.ID.String()] = url.Values{
+			g.ID.String() + ".firstname":         {g.Firstname},
+			g.ID.String() + ".invitation_status": {fmt.Sprint(int(model.InvitationStatusAccepted))},
+		}
+	}
+
+	// NOTE: all guests accept at the same time, only the free seats must be
+	// taken.
+	var wg sync.WaitGroup
+	for id, form := range forms {
+		wg.Add(1)
+		go func(id string, form url.Values) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/"+id+"/submit", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("submit %s: got status %d: %s", id, rec.Code, rec.Body)
+			}
+		}(id, form)
+	}
+	wg.Wait()
+
+	guests, err = s.gStore.ListGuests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotAccepted, gotWaitlisted int
+	for _, g := range guests {
+		switch g.InvitationStatus {
+		case model.InvitationStatusAccepted:
+			gotAccepted++
+		case model.InvitationStatusWaitlisted:
+			gotWaitlisted++
+		}
+	}
+	if gotAccepted != event.Capacity {
+		t.Errorf("got %d accepted guests, want the capacity of %d", gotAccepted, event.Capacity)
+	}
+	if gotWaitlisted != invitations-free {
+		t.Errorf("got %d waitlisted guests, want %d", gotWaitlisted, invitations-free)
+	}
+}
+
+func TestEventChanges_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+
+	before, err := s.eStore.GetEvent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: the event is read and written as a whole, so changes of
+	// different parts of it must not overwrite each other.
+	const n = 10
+	paths := []string{"/admin/event/questions", "/admin/event/gifts", "/admin/event/sub-events", "/admin/event/hotels"}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		for _, path := range paths {
+			wg.Add(1)
+			go func(path string) {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, path, nil)
+				req.SetBasicAuth("admin", "admin")
+				rec := httptest.NewRecorder()
+				s.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Errorf("POST %s: got status %d: %s", path, rec.Code, rec.Body)
+				}
+			}(path)
+		}
+	}
+	wg.Wait()
+
+	after, err := s.eStore.GetEvent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, got := range map[string][2]int{
+		"questions":  {len(before.Questions), len(after.Questions)},
+		"gifts":      {len(before.Gifts), len(after.Gifts)},
+		"sub-events": {len(before.SubEvents), len(after.SubEvents)},
+		"hotels":     {len(before.Hotels), len(after.Hotels)},
+	} {
+		if got[1] != got[0]+n {
+			t.Errorf("got %d %s, want %d", got[1], name, got[0]+n)
+		}
+	}
+}
+
+func TestSubmit_Status(t *testing.T) {
+	tt := []struct {
+		name     string
+		status   model.InvitationStatus
+		wantCode int
+		want     model.InvitationStatus
+	}{
+		{name: "accept", status: model.InvitationStatusAccepted, wantCode: http.StatusOK, want: model.InvitationStatusAccepted},
+		{name: "reject", status: model.InvitationStatusRejected, wantCode: http.StatusOK, want: model.InvitationStatusRejected},
+		{name: "join waitlist", status: model.InvitationStatusWaitlisted, wantCode: http.StatusBadRequest, want: model.InvitationStatusNotAnswered},
+		{name: "unknown", status: model.InvitationStatusUnknown, wantCode: http.StatusBadRequest, want: model.InvitationStatusNotAnswered},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestServer(t)
+			event, err := s.eStore.GetEvent(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			event.Deadline = nil
+			if err := s.eStore.UpdateEvent(ctx, event); err != nil {
+				t.Fatal(err)
+			}
+
+			g := &model.Guest{Firstname: "Guest", InvitationStatus: model.InvitationStatusNotAnswered}
+			if _, err := s.gStore.CreateGuest(ctx, g); err != nil {
+				t.Fatal(err)
+			}
+			inv, err := s.iStore.CreateInvitation(ctx, g.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			form := url.Values{
+				g.ID.String() + ".firstname":         {g.Firstname},
+				g.ID.String() + ".invitation_status": {fmt.Sprint(int(tc.status))},
+			}
+			req := httptest.NewRequest(http.MethodPost, "/"+inv.ID.String()+"/submit", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != tc.wantCode {
+				t.Errorf("got status code %d, want %d", rec.Code, tc.wantCode)
+			}
+
+			got, err := s.gStore.GetGuestByID(ctx, g.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.InvitationStatus != tc.want {
+				t.Errorf("got status %d, want %d", got.InvitationStatus, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreateGuest_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	event, err := s.eStore.GetEvent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.Deadline = nil
+	if err := s.eStore.UpdateEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &model.Guest{Firstname: "Guest", InvitationStatus: model.InvitationStatusNotAnswered}
+	if _, err := s.gStore.CreateGuest(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := s.iStore.CreateInvitation(ctx, g.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv.MaxGuests = 3
+	if err := s.iStore.UpdateInvitation(ctx, inv); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: the guests add more plus-ones at the same time than fit into the
+	// invitation, the rest must be rejected.
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPut, "/"+inv.ID.String()+"/guests", nil)
+			req.Header.Set("Hx-Request", "true")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK && rec.Code != http.StatusForbidden {
+				t.Errorf("got status %d: %s", rec.Code, rec.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := s.iStore.GetInvitationByID(ctx, inv.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.GuestIDs) != inv.MaxGuests {
+		t.Errorf("got %d guests, want the limit of %d", len(got.GuestIDs), inv.MaxGuests)
+	}
+}
+
+func TestUpdateInvitation_Limits(t *testing.T) {
+	tt := []struct {
+		name            string
+		form            url.Values
+		wantMaxGuests   int
+		wantMaxPlusOnes int
+	}{
+		{name: "keep", form: url.Values{}, wantMaxGuests: 4, wantMaxPlusOnes: 2},
+		{name: "change", form: url.Values{"max_guests": {"6"}, "max_plus_ones": {"3"}}, wantMaxGuests: 6, wantMaxPlusOnes: 3},
+		{name: "clear", form: url.Values{"max_guests": {""}, "max_plus_ones": {""}}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestServer(t)
+			g := &model.Guest{Firstname: "Guest"}
+			if _, err := s.gStore.CreateGuest(ctx, g); err != nil {
+				t.Fatal(err)
+			}
+			inv, err := s.iStore.CreateInvitation(ctx, g.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inv.MaxGuests, inv.MaxPlusOnes = 4, 2
+			if err := s.iStore.UpdateInvitation(ctx, inv); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/invitation/"+inv.ID.String(), strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetBasicAuth("admin", "admin")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+			}
+
+			got, err := s.iStore.GetInvitationByID(ctx, inv.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.MaxGuests != tc.wantMaxGuests || got.MaxPlusOnes != tc.wantMaxPlusOnes {
+				t.Errorf("got limits %d/%d, want %d/%d", got.MaxGuests, got.MaxPlusOnes, tc.wantMaxGuests, tc.wantMaxPlusOnes)
+			}
+		})
+	}
+}
+
+func TestDefaultLanguage(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	event, err := s.eStore.GetEvent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	event.Deadline = &past
+	if err := s.eStore.UpdateEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &model.Guest{Firstname: "Guest"}
+	if _, err := s.gStore.CreateGuest(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := s.iStore.CreateInvitation(ctx, g.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv.DefaultLanguage = "de"
+	if err := s.iStore.UpdateInvitation(ctx, inv); err != nil {
+		t.Fatal(err)
+	}
+
+	translation, err := s.tStore.ByLanguage(ctx, "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{method: http.MethodGet, path: "/" + inv.ID.String(), want: `<html lang="de"`},
+		// NOTE: the deadline error is shown in the default language, too.
+		{method: http.MethodPost, path: "/" + inv.ID.String() + "/submit", want: translation.Error.Title},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Accept-Language", "en")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("%s %s: got no %q in the page", tc.method, tc.path, tc.want)
+		}
+	}
+}
+
+func TestTranslations_DefaultLanguage(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+
+	invites := make(map[string]uuid.UUID)
+	for _, lang := range []string{"de", "en", ""} {
+		g := &model.Guest{Firstname: "Guest"}
+		if _, err := s.gStore.CreateGuest(ctx, g); err != nil {
+			t.Fatal(err)
+		}
+		inv, err := s.iStore.CreateInvitation(ctx, g.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inv.DefaultLanguage = lang
+		if err := s.iStore.UpdateInvitation(ctx, inv); err != nil {
+			t.Fatal(err)
+		}
+		invites[lang] = inv.ID
+	}
+
+	steps := []struct {
+		name   string
+		method string
+		path   string
+		form   url.Values
+		want   map[string]string
+	}{
+		{
+			name:   "rename",
+			method: http.MethodPost,
+			path:   "/admin/translations/de/rename",
+			form:   url.Values{"to": {"de-AT"}},
+			want:   map[string]string{"de": "de-AT", "en": "en", "": ""},
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/admin/translations/de-AT",
+			want:   map[string]string{"de": "", "en": "en", "": ""},
+		},
+	}
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, strings.NewReader(step.form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("admin", "admin")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("%s: got status %d: %s", step.name, rec.Code, rec.Body)
+		}
+
+		for lang, id := range invites {
+			inv, err := s.iStore.GetInvitationByID(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inv.DefaultLanguage != step.want[lang] {
+				t.Errorf("%s: got default language %q for %q, want %q", step.name, inv.DefaultLanguage, lang, step.want[lang])
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Behavior of the pages. It is served as a file instead of inline scripts and
+// event handler attributes, which the Content-Security-Policy forbids.
+(function () {
+  // data-countdown shows the time left until a date, e.g. "(in 3 days)", in
+  // the language of the page.
+  function renderCountdowns(root) {
+    const rtf = new Intl.RelativeTimeFormat(
+      document.documentElement.lang || undefined,
+      { numeric: "auto" },
+    );
+    const units = [
+      ["day", 86400000],
+      ["hour", 3600000],
+      ["minute", 60000],
+    ];
+    root.querySelectorAll("[data-countdown]").forEach(function (el) {
+      const diff = new Date(el.dataset.countdown) - Date.now();
+      for (const [unit, ms] of units) {
+        if (Math.abs(diff) >= ms || unit === "minute") {
+          el.textContent = "(" + rtf.format(Math.round(diff / ms), unit) + ")";
+          break;
+        }
+      }
+    });
+  }
+
+  function initRipple() {
+    if (window.te) {
+      window.te.initTE({ Ripple: window.te.Ripple }, { allowReinits: true });
+    }
+  }
+
+  document.addEventListener("DOMContentLoaded", function () {
+    renderCountdowns(document);
+    initRipple();
+  });
+
+  document.body.addEventListener("htmx:load", function (event) {
+    renderCountdowns(event.detail.elt);
+  });
+
+  // data-scroll-to scrolls to the element with the given ID after the
+  // request of the element itself, but not of the elements within.
+  document.body.addEventListener("htmx:afterRequest", function (event) {
+    const id = event.detail.elt.dataset.scrollTo;
+    if (id) {
+      document.getElementById(id)?.scrollIntoView({ behavior: "smooth" });
+    }
+  });
+
+  document.addEventListener("click", function (event) {
+    // data-copy copies its value to the clipboard.
+    const copy = event.target.closest("[data-copy]");
+    if (copy) {
+      navigator.clipboard.writeText(copy.dataset.copy);
+      return;
+    }
+    // data-dismiss removes the closest element with the given selector,
+    // e.g. a toast.
+    const dismiss = event.target.closest("[data-dismiss]");
+    if (dismiss) {
+      dismiss.closest(dismiss.dataset.dismiss)?.remove();
+    }
+  });
+})();
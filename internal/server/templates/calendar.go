@@ -0,0 +1,274 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/ical"
+	"github.com/quixsi/core/internal/model"
+)
+
+// calendarProdID identifies the product in the calendars it creates.
+const calendarProdID = "-//quixsi//core//EN"
+
+// calendarRefreshInterval is how often subscribers of the admin feed are asked
+// to update it.
+const calendarRefreshInterval = time.Hour
+
+// calendarTranslation returns the translation used for a calendar. Without a
+// requested language, the first language is used.
+func (p *GuestHandler) calendarTranslation(ctx context.Context, lang string) (*model.Translation, string, error) {
+	if lang == "" {
+		langs, err := p.tStore.ListLanguages(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(langs) == 0 {
+			return nil, "", fmt.Errorf("no languages")
+		}
+		sort.Strings(langs)
+		lang = langs[0]
+	}
+	translation, err := translationWithFallback(ctx, p.tStore, lang)
+	if err != nil {
+		return nil, "", err
+	}
+	return translation, lang, nil
+}
+
+// calendarEvent converts the main event into a calendar event.
+func calendarEvent(c *gin.Context, event *model.Event, summary string) ical.Event {
+	res := ical.Event{
+		UID:     calendarUID(c, "event"),
+		Start:   event.Date.In(event.TimeZone()),
+		Summary: summary,
+	}
+	if event.Location != nil {
+		res.Location = locationText(event.Location)
+		res.Latitude, res.Longitude = event.Latitude, event.Longitude
+	}
+	return res
+}
+
+// calendarSubEvent converts a sub-event into a calendar event.
+func calendarSubEvent(c *gin.Context, s *model.SubEvent, lang string, tz *time.Location) ical.Event {
+	return ical.Event{
+		UID:       calendarUID(c, s.ID.String()),
+		Start:     s.Date.In(tz),
+		Summary:   s.Label(lang),
+		Location:  locationText(&s.Location),
+		Latitude:  s.Location.Latitude,
+		Longitude: s.Location.Longitude,
+	}
+}
+
+// calendarUID returns a globally unique ID for the given part of the event,
+// which stays the same across downloads.
+func calendarUID(c *gin.Context, id string) string {
+	host := c.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return id + "@" + host
+}
+
+// locationText returns the name and address of a location in a single line.
+func locationText(l *model.Location) string {
+	var parts []string
+	add := func(values ...string) {
+		if s := strings.TrimSpace(strings.Join(values, " ")); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	add(l.Name)
+	add(l.Street, l.StreetNumber)
+	add(l.ZipCode, l.City)
+	add(l.Country)
+	return strings.Join(parts, ", ")
+}
+
+// absoluteURL returns the URL of the given path on the requested host.
+func absoluteURL(c *gin.Context, path string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + path
+}
+
+func (p *GuestHandler) writeCalendar(ctx context.Context, c *gin.Context, filename string, cal *ical.Calendar) {
+	span := trace.SpanFromContext(ctx)
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if err := cal.Encode(c.Writer); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not write calendar")
+		p.logger.ErrorContext(ctx, "could not write calendar", "error", err)
+	}
+}
+
+// ExportCalendar writes the event and the sub-events the guests of the
+// invitation are invited to as iCalendar file.
+func (p *GuestHandler) ExportCalendar(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ExportCalendar")
+	defer span.End()
+
+	inviteID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid invitation ID")
+		return
+	}
+
+	lang, err := guestLanguage(ctx, c, p.tStore)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list languages")
+		p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+		c.String(http.StatusInternalServerError, "could not list languages")
+		return
+	}
+	translation, lang, err := p.calendarTranslation(ctx, lang)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unknown target language")
+		p.logger.ErrorContext(ctx, "unknown target language", "error", err)
+		c.String(http.StatusBadRequest, "unknown target language")
+		return
+	}
+
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	link := absoluteURL(c, "/"+inviteID.String()+"?lang="+url.QueryEscape(lang))
+	ev := calendarEvent(c, event, translation.Title)
+	ev.URL = link
+	cal := &ical.Calendar{
+		ProdID: calendarProdID,
+		Stamp:  time.Now(),
+		Events: []ical.Event{ev},
+	}
+	for _, s := range event.SubEventsFor(inviteID) {
+		sub := calendarSubEvent(c, s, lang, event.TimeZone())
+		sub.URL = link
+		cal.Events = append(cal.Events, sub)
+	}
+
+	p.writeCalendar(ctx, c, "event.ics", cal)
+}
+
+// ExportAdminCalendar writes a calendar feed of the event, all sub-events and
+// the deadline for subscription by the admins. The descriptions contain the
+// current answers of the guests.
+func (p *GuestHandler) ExportAdminCalendar(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ExportAdminCalendar")
+	defer span.End()
+
+	translation, lang, err := p.calendarTranslation(ctx, c.Query("lang"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unknown target language")
+		p.logger.ErrorContext(ctx, "unknown target language", "error", err)
+		c.String(http.StatusBadRequest, "unknown target language")
+		return
+	}
+
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	invs, err := p.iStore.ListInvitations(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list invitations")
+		p.logger.ErrorContext(ctx, "could not list invitations", "error", err)
+		c.String(http.StatusInternalServerError, "could not list invitations")
+		return
+	}
+
+	var accepted, rejected, pending int
+	subEvents := adminSubEvents(event, nil)
+	for _, inv := range invs {
+		for _, gID := range inv.GuestIDs {
+			g, err := p.gStore.GetGuestByID(ctx, gID)
+			if err != nil {
+				p.logger.WarnContext(ctx, "could not read guest", "error", err, "id", gID.String())
+				continue
+			}
+			for _, s := range subEvents {
+				s.count(inv.ID, g)
+			}
+			switch g.InvitationStatus {
+			case model.InvitationStatusAccepted:
+				accepted++
+			case model.InvitationStatusRejected:
+				rejected++
+			default:
+				pending++
+			}
+		}
+	}
+
+	link := absoluteURL(c, "/admin/")
+	ev := calendarEvent(c, event, translation.Title)
+	ev.Description = answersText(accepted, rejected, pending)
+	ev.URL = link
+	cal := &ical.Calendar{
+		ProdID:          calendarProdID,
+		Name:            translation.Title,
+		RefreshInterval: calendarRefreshInterval,
+		Stamp:           time.Now(),
+		Events:          []ical.Event{ev},
+	}
+	for _, s := range subEvents {
+		sub := calendarSubEvent(c, s.SubEvent, lang, event.TimeZone())
+		sub.Description = answersText(s.Accepted, s.Rejected, s.Pending)
+		sub.URL = link
+		cal.Events = append(cal.Events, sub)
+	}
+	if event.Deadline != nil {
+		cal.Events = append(cal.Events, ical.Event{
+			UID:     calendarUID(c, "deadline"),
+			Start:   event.Deadline.In(event.TimeZone()),
+			Summary: translation.Title + ": " + translation.GuestForm.LabelDeadline,
+			URL:     link,
+		})
+	}
+
+	p.writeCalendar(ctx, c, "admin.ics", cal)
+}
+
+func answersText(accepted, rejected, pending int) string {
+	return fmt.Sprintf("%d accepted, %d declined, %d pending", accepted, rejected, pending)
+}
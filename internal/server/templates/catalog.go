@@ -0,0 +1,378 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/quixsi/core/internal/catalog"
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/richtext"
+)
+
+// maxCatalogSize is the maximum size of an imported file in bytes.
+const maxCatalogSize = 4 << 20
+
+// ExportTranslations writes the texts of the language given by the query
+// parameter "lang", or of all languages, as file in the format given by the
+// query parameter "format". The texts of the reference language are added as
+// sources for translators. PO files of several languages are zipped.
+func (t *TranslationHandler) ExportTranslations(c *gin.Context) {
+	ctx, span := tracer.Start(c.Request.Context(), "TranslationHandler.ExportTranslations")
+	defer span.End()
+
+	format := catalog.Format(c.DefaultQuery("format", string(catalog.FormatJSON)))
+	if !slices.Contains(catalog.Formats, format) {
+		err := fmt.Errorf("%q: %w", format, catalog.ErrUnknownFormat)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	catalogs, err := t.catalogs(ctx, c.Query("lang"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not read translations")
+		t.logger.ErrorContext(ctx, "could not read translations", "error", err)
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filename := "translations"
+	if lang := c.Query("lang"); lang != "" {
+		filename += "." + lang
+	}
+
+	var buf bytes.Buffer
+	contentType := format.ContentType()
+	switch {
+	case format == catalog.FormatJSON:
+		err = catalog.EncodeJSON(&buf, catalogs)
+	case format == catalog.FormatXLIFF:
+		err = catalog.EncodeXLIFF(&buf, catalogs)
+	case len(catalogs) == 1:
+		err = catalog.EncodePO(&buf, catalogs[0])
+	default:
+		contentType, filename = "application/zip", filename+".zip"
+		err = writePOArchive(&buf, catalogs)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not write translations")
+		t.logger.ErrorContext(ctx, "could not write translations", "error", err)
+		c.String(http.StatusInternalServerError, "could not write translations")
+		return
+	}
+	if contentType != "application/zip" {
+		filename += format.Extension()
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// catalogs returns the catalogs of a language or, if lang is empty, of all
+// languages.
+func (t *TranslationHandler) catalogs(ctx context.Context, lang string) ([]*catalog.Catalog, error) {
+	langs := []string{lang}
+	if lang == "" {
+		var err error
+		if langs, err = t.tStore.ListLanguages(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var sources map[string]string
+	if ref, err := t.tStore.ByLanguage(ctx, model.FallbackLanguage); err == nil {
+		sources = catalogTexts(ref)
+	}
+
+	res := make([]*catalog.Catalog, 0, len(langs))
+	for _, l := range langs {
+		translation, err := t.tStore.ByLanguage(ctx, l)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find language %q: %w", l, err)
+		}
+		c := &catalog.Catalog{Language: l, Texts: catalogTexts(translation)}
+		if sources != nil {
+			c.SourceLanguage, c.Sources = model.FallbackLanguage, sources
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// catalogTexts returns the texts of a translation for translators. The flag
+// is an image and left out.
+func catalogTexts(translation *model.Translation) map[string]string {
+	texts := translation.Texts()
+	delete(texts, "flag_img_src")
+	return texts
+}
+
+func writePOArchive(w io.Writer, catalogs []*catalog.Catalog) error {
+	zw := zip.NewWriter(w)
+	for _, c := range catalogs {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     c.Language + catalog.FormatPO.Extension(),
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := catalog.EncodePO(f, c); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// importChange is a text that an import changes.
+type importChange struct {
+	Key, Old, New string
+}
+
+// importLanguage describes how an import changes a language.
+type importLanguage struct {
+	Language string
+	// New is set if the language does not exist yet.
+	New     bool
+	Changed []importChange
+	// Missing are the keys of texts the file does not contain. They are
+	// left unchanged.
+	Missing []string
+	// Unknown are the keys in the file that belong to no text. They are
+	// ignored.
+	Unknown []string
+	// Error explains why the language can not be imported.
+	Error string
+
+	translation *model.Translation
+}
+
+// prepareImport merges the texts of the catalogs into the stored
+// translations. Empty texts, e.g. untranslated messages of PO files, are
+// ignored.
+func (t *TranslationHandler) prepareImport(ctx context.Context, catalogs map[string]map[string]string) []*importLanguage {
+	var reference map[string]string
+	if ref, err := t.tStore.ByLanguage(ctx, model.FallbackLanguage); err == nil {
+		reference = catalogTexts(ref)
+	}
+
+	res := make([]*importLanguage, 0, len(catalogs))
+	for lang, texts := range catalogs {
+		l := &importLanguage{Language: lang}
+		res = append(res, l)
+		if err := model.CheckLanguage(lang); err != nil {
+			l.Error = err.Error()
+			continue
+		}
+
+		current, err := t.tStore.ByLanguage(ctx, lang)
+		if err != nil {
+			l.New = true
+			current = &model.Translation{}
+		}
+		currentTexts := current.Texts()
+		merged := *current
+		for key, text := range texts {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			if err := merged.SetText(key, text); err != nil {
+				l.Unknown = append(l.Unknown, key)
+				continue
+			}
+			if currentTexts[key] != text {
+				l.Changed = append(l.Changed, importChange{Key: key, Old: currentTexts[key], New: text})
+			}
+		}
+		merged.WelcomeMessage = richtext.Sanitize(merged.WelcomeMessage)
+		if err := checkTranslationTemplates(lang, &merged); err != nil {
+			l.Error = err.Error()
+		}
+
+		for _, m := range []map[string]string{reference, catalogTexts(current)} {
+			for key, text := range m {
+				if text != "" && strings.TrimSpace(texts[key]) == "" && !slices.Contains(l.Missing, key) {
+					l.Missing = append(l.Missing, key)
+				}
+			}
+		}
+
+		sort.Slice(l.Changed, func(i, j int) bool { return l.Changed[i].Key < l.Changed[j].Key })
+		sort.Strings(l.Missing)
+		sort.Strings(l.Unknown)
+		l.translation = &merged
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Language < res[j].Language })
+	return res
+}
+
+// PreviewImport reads an uploaded file of translations and shows which texts
+// an import would change, which are missing in the file and which keys are
+// unknown. Flat JSON files need the language in the form value "lang".
+func (t *TranslationHandler) PreviewImport(c *gin.Context) {
+	ctx, span := tracer.Start(c.Request.Context(), "TranslationHandler.PreviewImport")
+	defer span.End()
+
+	catalogs, err := readCatalogs(c)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.renderImport(ctx, c, gin.H{"Error": err.Error()})
+		return
+	}
+
+	byLanguage := make(map[string]map[string]string, len(catalogs))
+	for _, cat := range catalogs {
+		if _, ok := byLanguage[cat.Language]; ok {
+			err := fmt.Errorf("language %q is contained more than once", cat.Language)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			t.renderImport(ctx, c, gin.H{"Error": err.Error()})
+			return
+		}
+		byLanguage[cat.Language] = cat.Texts
+	}
+
+	languages := t.prepareImport(ctx, byLanguage)
+	data, err := json.Marshal(byLanguage)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.renderImport(ctx, c, gin.H{"Error": err.Error()})
+		return
+	}
+
+	valid := true
+	for _, l := range languages {
+		valid = valid && l.Error == ""
+	}
+	t.renderImport(ctx, c, gin.H{"Languages": languages, "Data": string(data), "Valid": valid})
+}
+
+func readCatalogs(c *gin.Context) ([]*catalog.Catalog, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCatalogSize+(4<<10))
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	format, err := catalog.DetectFormat(header.Filename, data)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case catalog.FormatPO:
+		cat, err := catalog.DecodePO(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("read PO file: %w", err)
+		}
+		return []*catalog.Catalog{cat}, nil
+	case catalog.FormatXLIFF:
+		catalogs, err := catalog.DecodeXLIFF(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("read XLIFF file: %w", err)
+		}
+		return catalogs, nil
+	default:
+		catalogs, err := catalog.DecodeJSON(bytes.NewReader(data), strings.TrimSpace(c.PostForm("lang")))
+		if err != nil {
+			return nil, fmt.Errorf("read JSON file: %w", err)
+		}
+		return catalogs, nil
+	}
+}
+
+// ApplyImport writes the texts shown by PreviewImport, which are sent back in
+// the form value "data". New languages are created.
+func (t *TranslationHandler) ApplyImport(c *gin.Context) {
+	ctx, span := tracer.Start(c.Request.Context(), "TranslationHandler.ApplyImport")
+	defer span.End()
+
+	var byLanguage map[string]map[string]string
+	if err := json.Unmarshal([]byte(c.PostForm("data")), &byLanguage); err != nil {
+		err := fmt.Errorf("read import: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	languages := t.prepareImport(ctx, byLanguage)
+
+	updates := make(map[string]*model.Translation)
+	for _, l := range languages {
+		if l.Error != "" {
+			err := errors.New(l.Language + ": " + l.Error)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		if !l.New {
+			updates[l.Language] = l.translation
+		}
+	}
+	if len(updates) > 0 {
+		if err := t.tStore.UpdateLanguages(ctx, updates); err != nil {
+			err := fmt.Errorf("update languages in store: %w", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	for _, l := range languages {
+		if !l.New {
+			continue
+		}
+		if err := t.tStore.CreateLanguage(ctx, l.Language, l.translation); err != nil {
+			err := fmt.Errorf("create language %q: %w", l.Language, err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusNoContent)
+}
+
+func (t *TranslationHandler) renderImport(ctx context.Context, c *gin.Context, data gin.H) {
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_TRANSLATIONS_IMPORT\" .}}")
+	tmpl, err := wrapperTemplate.ParseFS(templates, "admin.translations.import.html")
+	if err != nil {
+		t.logger.ErrorContext(ctx, "unable to parse import template", "error", err)
+		c.String(http.StatusInternalServerError, "unable to parse import template")
+		return
+	}
+	if err := tmpl.Execute(c.Writer, data); err != nil {
+		t.logger.ErrorContext(ctx, "unable to execute import template", "error", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/model"
+)
+
+var (
+	invitationStatusNames = map[model.InvitationStatus]string{
+		model.InvitationStatusUnknown:     "unknown",
+		model.InvitationStatusAccepted:    "accepted",
+		model.InvitationStatusRejected:    "rejected",
+		model.InvitationStatusNotAnswered: "not answered",
+		model.InvitationStatusWaitlisted:  "waitlisted",
+	}
+	ageCategoryNames = map[model.GuestAgeCategory]string{
+		model.GuestAgeCategoryUnknown:  "unknown",
+		model.GuestAgeCategoryBaby:     "baby",
+		model.GuestAgeCategoryTeenager: "teenager",
+		model.GuestAgeCategoryAdult:    "adult",
+	}
+	dietaryCategoryNames = map[model.DietaryCategory]string{
+		model.DietaryCategoryUnknown:    "unknown",
+		model.DietaryCategoryVegan:      "vegan",
+		model.DietaryCategoryVegetarian: "vegetarian",
+		model.DietaryCatagoryOmnivore:   "omnivore",
+	}
+)
+
+// ExportGuests writes all guests of all invitations as CSV, including their
+// answers to the questions of the event.
+func (p *GuestHandler) ExportGuests(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ExportGuests")
+	defer span.End()
+
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	invs, err := p.iStore.ListInvitations(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list invitations")
+		p.logger.ErrorContext(ctx, "could not list invitations", "error", err)
+		c.String(http.StatusInternalServerError, "could not list invitations")
+		return
+	}
+
+	header := []string{
+		"invitation", "guest", "firstname", "lastname", "status", "age",
+		"diet", "dietary tags", "allergens", "dietary note",
+	}
+	for _, q := range event.Questions {
+		header = append(header, q.Label("en"))
+	}
+
+	records := [][]string{header}
+	for _, inv := range invs {
+		for _, gID := range inv.GuestIDs {
+			g, err := p.gStore.GetGuestByID(ctx, gID)
+			if err != nil {
+				p.logger.WarnContext(ctx, "could not read guest", "error", err, "id", gID.String())
+				continue
+			}
+			record := []string{
+				inv.ID.String(),
+				g.ID.String(),
+				g.Firstname,
+				g.Lastname,
+				exportName(invitationStatusNames, g.InvitationStatus),
+				exportName(ageCategoryNames, g.AgeCategory),
+				exportName(dietaryCategoryNames, g.DietaryCategory),
+				strings.Join(g.DietaryTags, "; "),
+				strings.Join(g.Allergens, "; "),
+				g.DietaryNote,
+			}
+			for _, q := range event.Questions {
+				record = append(record, strings.Join(g.Answers[q.ID.String()], "; "))
+			}
+			records = append(records, record)
+		}
+	}
+	p.writeCSV(ctx, c, "guests.csv", records)
+}
+
+// writeCSV writes records as a CSV download named filename. All exports use
+// it, so their cells are neutralized by csvRecord.
+func (p *GuestHandler) writeCSV(ctx context.Context, c *gin.Context, filename string, records [][]string) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w := csv.NewWriter(c.Writer)
+	for _, record := range records {
+		if err := w.Write(csvRecord(record)); err != nil {
+			span := trace.SpanFromContext(ctx)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not write csv")
+			p.logger.ErrorContext(ctx, "could not write csv", "error", err, "file", filename)
+			return
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not write csv")
+		p.logger.ErrorContext(ctx, "could not write csv", "error", err, "file", filename)
+	}
+}
+
+// csvRecord neutralizes the cells of record that a spreadsheet would run as
+// a formula, by prefixing them with a quote. Guests enter most of the values.
+func csvRecord(record []string) []string {
+	for i, cell := range record {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			record[i] = "'" + cell
+		}
+	}
+	return record
+}
+
+func exportName[T ~int](names map[T]string, v T) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return strconv.Itoa(int(v))
+}
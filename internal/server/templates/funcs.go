@@ -0,0 +1,118 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quixsi/core/internal/locale"
+	"github.com/quixsi/core/internal/model"
+)
+
+// translationFuncs returns the functions available in the greeting and the
+// welcome message of a translation. They format their arguments in the
+// locale of the translation and dates in the time zone of the event:
+//
+//	join LIST              joins strings, e.g. "Anna, Ben and Carl"
+//	firstnames             the first names of the invited guests, joined
+//	guestcount             the number of invited guests
+//	plural N ONE OTHER     ONE if N takes the singular, otherwise OTHER
+//	number X DECIMALS      formats a number, e.g. "1.234,50" in German
+//	currency X CODE        formats money in an ISO 4217 currency, e.g. "25,00 €"
+//	eventdate              the date and time of the event
+//	date T                 formats the date of T, e.g. "Samstag, 2. März 2024"
+//	daymonth T             formats the day and month of T, e.g. "2. März"
+//	time T                 formats the time of day of T, e.g. "18:30"
+//	datetime T             formats the date and time of T including the time zone
+//	formatdate T LAYOUT    formats T using a layout of the time package
+//
+// The conjunction of join and firstnames is the "and" of the translation, if
+// it has one.
+func translationFuncs(lang string, translation *model.Translation, event *model.Event, guests []*model.Guest) map[string]any {
+	loc := locale.For(translation.Tag(lang))
+	tz := event.TimeZone()
+	and := strings.TrimSpace(translation.And)
+
+	firstnames := make([]string, 0, len(guests))
+	for _, g := range guests {
+		firstnames = append(firstnames, g.Firstname)
+	}
+
+	return map[string]any{
+		"join": func(items []string) string {
+			return loc.JoinList(items, and)
+		},
+		"firstnames": func() string {
+			return loc.JoinList(firstnames, and)
+		},
+		"guestcount": func() int {
+			return len(guests)
+		},
+		"plural": func(n int, one, other string) string {
+			return loc.Plural(n, one, other)
+		},
+		"number": func(x any, decimals int) (string, error) {
+			f, err := toFloat(x)
+			if err != nil {
+				return "", err
+			}
+			return loc.FormatNumber(f, decimals), nil
+		},
+		"currency": func(x any, code string) (string, error) {
+			f, err := toFloat(x)
+			if err != nil {
+				return "", err
+			}
+			return loc.FormatCurrency(f, code), nil
+		},
+		"eventdate": func() time.Time {
+			return event.Date.In(tz)
+		},
+		"date": func(t time.Time) string {
+			return loc.Format(t.In(tz), loc.DateLayout)
+		},
+		"daymonth": func(t time.Time) string {
+			return loc.Format(t.In(tz), loc.DayMonthLayout)
+		},
+		"time": func(t time.Time) string {
+			return loc.Format(t.In(tz), loc.TimeLayout)
+		},
+		"datetime": func(t time.Time) string {
+			return loc.Format(t.In(tz), loc.DateTimeLayout())
+		},
+		"formatdate": func(t time.Time, layout string) string {
+			return loc.Format(t.In(tz), layout)
+		},
+	}
+}
+
+func toFloat(x any) (float64, error) {
+	switch v := x.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("%v is not a number", x)
+	}
+}
+
+// checkTranslationTemplates reports an error if the greeting or the welcome
+// message of a translation cannot be parsed.
+func checkTranslationTemplates(lang string, translation *model.Translation) error {
+	funcs := translationFuncs(lang, translation, &model.Event{}, nil)
+	for field, msg := range map[string]string{
+		"greeting":        translation.Greeting,
+		"welcome_message": translation.WelcomeMessage,
+	} {
+		if _, err := parseTemplate(msg, funcs); err != nil {
+			return fmt.Errorf("%s.%s: %w", lang, field, err)
+		}
+	}
+	return nil
+}
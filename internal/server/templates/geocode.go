@@ -0,0 +1,203 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/geocode"
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/parser/form"
+)
+
+// geocodeTimeout limits the lookup of a single address.
+const geocodeTimeout = 10 * time.Second
+
+// geocodeNote tells the admin about the coordinates of a location.
+type geocodeNote struct {
+	Location *model.Location
+	Message  string
+	// Filled is set if the coordinates were taken from the address.
+	Filled bool
+	// Warning is set if the coordinates need attention.
+	Warning bool
+}
+
+// locationAddress returns the postal address of a location.
+func locationAddress(l *model.Location) geocode.Address {
+	return geocode.Address{
+		Street:       l.Street,
+		StreetNumber: l.StreetNumber,
+		ZipCode:      l.ZipCode,
+		City:         l.City,
+		Country:      l.Country,
+	}
+}
+
+func formatCoordinate(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// geocodeLocation fills the coordinates of a location from its address if
+// they are not set. Coordinates that are set are checked against the address
+// if either changed since prev. kind names the location in messages if it
+// has no name, e.g. "a hotel". It returns nil if there is nothing to tell.
+func (p *GuestHandler) geocodeLocation(ctx context.Context, l, prev *model.Location, kind string) *geocodeNote {
+	address := locationAddress(l)
+	if p.geocoder == nil || address.IsZero() {
+		return nil
+	}
+	unset := l.Latitude == 0 && l.Longitude == 0
+	if !unset && prev != nil && address == locationAddress(prev) &&
+		l.Latitude == prev.Latitude && l.Longitude == prev.Longitude {
+		return nil
+	}
+	name := kind
+	if l.Name != "" {
+		name = l.Name
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, geocodeTimeout)
+	defer cancel()
+	point, err := p.geocoder.Geocode(ctx, address)
+	if errors.Is(err, geocode.ErrNotFound) {
+		msg := fmt.Sprintf("The address of %s was not found, please check it.", name)
+		if unset {
+			msg = fmt.Sprintf("The address of %s was not found, please enter its coordinates.", name)
+		}
+		return &geocodeNote{Location: l, Message: msg, Warning: true}
+	}
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "could not geocode address", "address", address.String(), "error", err)
+		return &geocodeNote{
+			Location: l,
+			Message:  fmt.Sprintf("The address of %s could not be looked up.", name),
+			Warning:  true,
+		}
+	}
+
+	if unset {
+		l.Latitude, l.Longitude = point.Latitude, point.Longitude
+		return &geocodeNote{
+			Location: l,
+			Message: fmt.Sprintf("The coordinates of %s were taken from its address: %s, %s.",
+				name, formatCoordinate(l.Latitude), formatCoordinate(l.Longitude)),
+			Filled: true,
+		}
+	}
+	if d := geocode.Distance(point, geocode.Point{Latitude: l.Latitude, Longitude: l.Longitude}); d > geocode.MaxMismatch {
+		return &geocodeNote{
+			Location: l,
+			Message: fmt.Sprintf("The coordinates of %s are %.1f km away from its address, which is at %s, %s.",
+				name, d/1000, formatCoordinate(point.Latitude), formatCoordinate(point.Longitude)),
+			Warning: true,
+		}
+	}
+	return nil
+}
+
+// geocodeForm geocodes the locations of e as they are changed by forms, the
+// parsed form of UpdateEvent. The notes are keyed by the ID of the form. As
+// the lookups may take seconds, e is a snapshot that is not stored; the notes
+// are applied to the locked event with applyGeocodeNote.
+func (p *GuestHandler) geocodeForm(ctx context.Context, e *model.Event, forms map[string]url.Values) map[uuid.UUID]*geocodeNote {
+	notes := make(map[uuid.UUID]*geocodeNote)
+	for id, data := range forms {
+		lID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		prev := eventLocation(e, lID)
+		if prev == nil {
+			continue
+		}
+		kind := "an airport"
+		if lID == e.ID {
+			kind = "the venue"
+		} else if slices.ContainsFunc(e.Hotels, func(h *model.Location) bool { return h.ID == lID }) {
+			kind = "a hotel"
+		}
+		// NOTE: errors of the form are reported when it is applied.
+		l := *prev
+		if err := form.Unmarshal(data, &l); err != nil {
+			continue
+		}
+		if note := p.geocodeLocation(ctx, &l, prev, kind); note != nil {
+			notes[lID] = note
+		}
+	}
+	return notes
+}
+
+// applyGeocodeNote applies a note of geocodeForm to l, the same location of
+// the locked event. Filled coordinates are dropped if l got coordinates in
+// the meantime.
+func applyGeocodeNote(note *geocodeNote, l *model.Location) *geocodeNote {
+	if note == nil {
+		return nil
+	}
+	if note.Filled {
+		if l.Latitude != 0 || l.Longitude != 0 {
+			return nil
+		}
+		l.Latitude, l.Longitude = note.Location.Latitude, note.Location.Longitude
+	}
+	note.Location = l
+	return note
+}
+
+// renderGeocodeNotes shows the notes in a toast in the admin area and
+// updates the coordinates that were filled in the form.
+func (p *GuestHandler) renderGeocodeNotes(c *gin.Context, notes []*geocodeNote) {
+	if len(notes) == 0 {
+		return
+	}
+	ctx := c.Request.Context()
+
+	toast, title := "TOAST_SUCCESS", "Coordinates"
+	var messages []string
+	var filled []*model.Location
+	for _, n := range notes {
+		messages = append(messages, n.Message)
+		if n.Warning {
+			toast, title = "TOAST_ERROR", "Warning"
+		}
+		if n.Filled {
+			filled = append(filled, n.Location)
+		}
+	}
+
+	// NOTE: the IDs of the inputs contain dots, so the out of band swaps
+	// select them by attribute.
+	wrapperTemplate, _ := template.New("wrapper").Funcs(mediaFuncs).Parse(`{{ template "` + toast + `" .toast }}` +
+		`{{ range .filled }}<div hx-swap-oob="innerHTML:[id='{{ .ID }}.coordinates']">{{ template "ADMIN_LOCATION_COORDINATES" . }}</div>{{ end }}`)
+	t, err := wrapperTemplate.ParseFS(templates, "toast.error.html", "toast.success.html", "admin.event.location.html", "admin.location.photos.html")
+	if err != nil {
+		p.logger.ErrorContext(ctx, "unable to parse geocoding templates", "error", err)
+		return
+	}
+
+	c.Header("HX-Retarget", "#toast-container")
+	c.Header("HX-Reswap", "beforeend")
+	if err := t.Execute(c.Writer, gin.H{
+		"toast":  gin.H{"Title": title, "Message": strings.Join(messages, " ")},
+		"filled": filled,
+	}); err != nil {
+		p.logger.ErrorContext(ctx, "unable to execute geocoding templates", "error", err)
+	}
+}
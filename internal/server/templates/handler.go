@@ -5,18 +5,24 @@ package templates
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	txttemplate "text/template"
 	"time"
 	_ "time/tzdata"
@@ -28,19 +34,90 @@ import (
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/quixsi/core/internal/blob"
 	"github.com/quixsi/core/internal/db"
+	"github.com/quixsi/core/internal/geocode"
+	"github.com/quixsi/core/internal/locale"
 	"github.com/quixsi/core/internal/model"
 	"github.com/quixsi/core/internal/parser/form"
+	"github.com/quixsi/core/internal/richtext"
+	"github.com/quixsi/core/internal/server/assets"
 )
 
 //go:embed *.html
 var templates embed.FS
 
+// DeadlineKey is the key under which the response deadline of the requested
+// invitation is stored in the request context.
+const DeadlineKey = "deadline"
+
+// DefaultLanguageKey is the key under which the default language of the
+// requested invitation is stored in the request context.
+const DefaultLanguageKey = "default-language"
+
+// invitationFuncs are the functions available in the invitation templates.
+var invitationFuncs = template.FuncMap{
+	// richtext renders admin-authored rich text, e.g. the welcome message.
+	"richtext": richtext.HTML,
+	// guestFields passes the page data together with a guest to the
+	// "guest-fields" template, which takes a single argument.
+	"guestFields": func(page gin.H, guest *model.Guest) gin.H {
+		return gin.H{"page": page, "guest": guest}
+	},
+}
+
+// pageFuncs are the functions available in all pages.
+func pageFuncs(static *assets.Manifest) template.FuncMap {
+	funcs := template.FuncMap{
+		// asset returns the URL of a static file, e.g. "js/app.js".
+		"asset": static.Path,
+		// flag returns an uploaded flag as image URL, or "" for none.
+		"flag": flagURL,
+	}
+	maps.Copy(funcs, mediaFuncs)
+	return funcs
+}
+
+// assetCall matches the static files the templates refer to, e.g.
+// {{ asset "js/app.js" }}.
+var assetCall = regexp.MustCompile(`\basset "([^"]+)"`)
+
+// RequiredAssets returns the static files the pages refer to, so a missing
+// one can be reported at startup.
+func RequiredAssets() ([]string, error) {
+	names := []string{defaultBanner}
+	err := fs.WalkDir(templates, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(templates, name)
+		if err != nil {
+			return err
+		}
+		for _, m := range assetCall.FindAllSubmatch(data, -1) {
+			names = append(names, string(m[1]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// dateInputLayout is the format of datetime-local inputs.
+const dateInputLayout = "2006-01-02T15:04"
+
 func NewGuestHandler(
 	iStore db.InvitationStore,
 	tStore db.TranslationStore,
 	gStore db.GuestStore,
 	eStore db.EventStore,
+	sStore db.TableStore,
+	bStore blob.Store,
+	geocoder geocode.Provider,
+	static *assets.Manifest,
 ) *GuestHandler {
 	coreTemplates := []string{"main.html", "footer.html", "main.style.html"}
 	adminTemplates := []string{
@@ -49,9 +126,17 @@ func NewGuestHandler(
 		"admin.content.html",
 		"admin.event.html",
 		"admin.event.location.html",
+		"admin.location.photos.html",
 		"admin.event.location.airport.html",
 		"admin.event.location.hotel.html",
 		"admin.translations.html",
+		"admin.invitation-limits.html",
+		"admin.event.questions.html",
+		"admin.event.schedule.html",
+		"admin.event.registry.html",
+		"admin.theme.html",
+		"admin.travel.html",
+		"admin.seating.html",
 	}
 	invitationTemplates := []string{
 		"invitation.banner.html",
@@ -61,36 +146,67 @@ func NewGuestHandler(
 		"invitation.content.html",
 		"greeting.html",
 		"location.html",
+		"photos.html",
 		"date.html",
 		"guest-form.html",
+		"guest-fields.html",
 		"map.html",
 		"hotels.html",
 		"airports.html",
+		"registry.html",
 	}
 	languageTemplates := []string{"language.header.html", "language.content.html", "language-select.html"}
 
+	funcs := pageFuncs(static)
 	return &GuestHandler{
-		tmplAdmin: template.Must(template.ParseFS(templates, append(coreTemplates, adminTemplates...)...)),
-		// NOTE: workaround to allow html formatting
-		tmplForm: txttemplate.Must(txttemplate.ParseFS(templates, append(coreTemplates, invitationTemplates...)...)),
-		tmplLang: template.Must(template.ParseFS(templates, append(coreTemplates, languageTemplates...)...)),
-		iStore:   iStore,
-		gStore:   gStore,
-		tStore:   tStore,
-		eStore:   eStore,
-		logger:   slog.Default().WithGroup("http"),
+		tmplAdmin: template.Must(template.New(coreTemplates[0]).Funcs(funcs).ParseFS(templates, append(coreTemplates, adminTemplates...)...)),
+		tmplForm:  template.Must(template.New(coreTemplates[0]).Funcs(funcs).Funcs(invitationFuncs).ParseFS(templates, append(coreTemplates, invitationTemplates...)...)),
+		tmplLang:  template.Must(template.New(coreTemplates[0]).Funcs(funcs).ParseFS(templates, append(coreTemplates, languageTemplates...)...)),
+		static:    static,
+		iStore:    iStore,
+		gStore:    gStore,
+		tStore:    tStore,
+		eStore:    eStore,
+		sStore:    sStore,
+		bStore:    bStore,
+		geocoder:  geocoder,
+		logger:    slog.Default().WithGroup("http"),
 	}
 }
 
+// GuestHandler serves the pages of the guests and the admin area. It must be
+// created once and shared by all requests, as its mutexes serialize their
+// changes of the event and the guests.
 type GuestHandler struct {
 	tmplAdmin *template.Template
-	tmplForm  *txttemplate.Template
+	tmplForm  *template.Template
 	tmplLang  *template.Template
+	static    *assets.Manifest
 	iStore    db.InvitationStore
 	gStore    db.GuestStore
 	tStore    db.TranslationStore
 	eStore    db.EventStore
-	logger    *slog.Logger
+	sStore    db.TableStore
+	bStore    blob.Store
+	// geocoder fills the coordinates of locations, it is nil if geocoding
+	// is disabled.
+	geocoder geocode.Provider
+	logger   *slog.Logger
+
+	// capacityMu serializes status changes that depend on the event capacity.
+	capacityMu sync.Mutex
+	// seatingMu serializes changes of the seating plan, which may span
+	// several tables.
+	seatingMu sync.Mutex
+	// eventMu serializes the changes of the event. The event is read and
+	// written as a whole, and holds the gift claims of the guests as well as
+	// the settings of the admin, so concurrent changes would overwrite each
+	// other. Lock it before capacityMu.
+	eventMu sync.Mutex
+	// inviteMu serializes the changes of invitations, which are read and
+	// written as a whole, so concurrent changes of the guests of an invitation
+	// can not exceed its limits. Lock it before capacityMu.
+	inviteMu sync.Mutex
 }
 
 func NewErrorHandler(tStore db.TranslationStore) *ErrorHandler {
@@ -122,14 +238,34 @@ func (p *GuestHandler) RenderAdminOverview(c *gin.Context) {
 
 	langs, err := p.tStore.ListLanguages(c)
 	translations := make(map[string]map[string]string)
+	flags := make(map[string]template.URL)
 
 	for _, lang := range langs {
 		// TODO:: handle errors
 		translation, _ := p.tStore.ByLanguage(ctx, lang)
+		if translation != nil {
+			if flag := flagURL(translation.FlagImgSrc); flag != "" {
+				flags[lang] = flag
+			}
+		}
 		out, _ := json.Marshal(translation)
 		flattened, _ := flatten.FlattenString(string(out), "", flatten.DotStyle)
 		result := make(map[string]string)
 		_ = json.Unmarshal([]byte(flattened), &result)
+		// NOTE: make options configured for the event translatable even
+		// though no label exists for them yet.
+		for _, tag := range metadata.DietaryTagOptions() {
+			key := "guest_form.options_dietary_tags." + tag
+			result[key] = result[key]
+		}
+		for _, allergen := range metadata.AllergenOptions() {
+			key := "guest_form.options_allergens." + allergen
+			result[key] = result[key]
+		}
+		// NOTE: the locale and the direction are left out of the JSON if
+		// they are not set, but should be editable anyway.
+		result["locale"] = result["locale"]
+		result["direction"] = result["direction"]
 		translations[lang] = result
 	}
 	if err != nil {
@@ -139,6 +275,12 @@ func (p *GuestHandler) RenderAdminOverview(c *gin.Context) {
 		return
 	}
 
+	missingTexts, err := missingTranslations(ctx, p.tStore, model.FallbackLanguage)
+	if err != nil {
+		span.RecordError(err)
+		p.logger.WarnContext(ctx, "could not check translations", "error", err)
+	}
+
 	invs, err := p.iStore.ListInvitations(ctx)
 	if err != nil {
 		span.RecordError(err)
@@ -150,10 +292,12 @@ func (p *GuestHandler) RenderAdminOverview(c *gin.Context) {
 
 	status := struct {
 		Invitations struct {
-			Total    int
-			Pending  int
-			Accepted int
-			Rejected int
+			Total      int
+			Pending    int
+			Accepted   int
+			Rejected   int
+			Waitlisted int
+			Capacity   int
 		}
 		Diet struct {
 			Unknown    int
@@ -167,11 +311,21 @@ func (p *GuestHandler) RenderAdminOverview(c *gin.Context) {
 			Teenager int
 			Adult    int
 		}
-	}{}
+		DietaryTags map[string]int
+		Allergens   map[string]int
+	}{
+		DietaryTags: make(map[string]int),
+		Allergens:   make(map[string]int),
+	}
+
+	status.Invitations.Capacity = metadata.Capacity
 
+	subEvents := adminSubEvents(metadata, langs)
 	table := make(map[uuid.UUID][]*model.Guest, len(invs))
+	invitations := make(map[uuid.UUID]*model.Invitation, len(invs))
 
 	for _, inv := range invs {
+		invitations[inv.ID] = inv
 		for _, gID := range inv.GuestIDs {
 			guest, err := p.gStore.GetGuestByID(ctx, gID)
 			if err != nil {
@@ -182,6 +336,9 @@ func (p *GuestHandler) RenderAdminOverview(c *gin.Context) {
 				continue
 			}
 			status.Invitations.Total++
+			for _, s := range subEvents {
+				s.count(inv.ID, guest)
+			}
 			switch guest.InvitationStatus {
 			case model.InvitationStatusAccepted:
 				status.Invitations.Accepted += 1
@@ -205,8 +362,16 @@ func (p *GuestHandler) RenderAdminOverview(c *gin.Context) {
 				case model.GuestAgeCategoryAdult:
 					status.AgeCategory.Adult += 1
 				}
+				for _, tag := range guest.DietaryTags {
+					status.DietaryTags[tag] += 1
+				}
+				for _, allergen := range guest.Allergens {
+					status.Allergens[allergen] += 1
+				}
 			case model.InvitationStatusRejected:
 				status.Invitations.Rejected += 1
+			case model.InvitationStatusWaitlisted:
+				status.Invitations.Waitlisted += 1
 			default:
 				status.Invitations.Pending += 1
 			}
@@ -214,11 +379,46 @@ func (p *GuestHandler) RenderAdminOverview(c *gin.Context) {
 		}
 	}
 
+	seating, err := p.seatingPlan(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not load seating plan")
+		p.logger.ErrorContext(ctx, "could not load seating plan", "error", err)
+		c.String(http.StatusInternalServerError, "could not load seating plan")
+		return
+	}
+
+	travel, err := p.travelReport(ctx, defaultTravelWindow)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not create travel report")
+		p.logger.ErrorContext(ctx, "could not create travel report", "error", err)
+		c.String(http.StatusInternalServerError, "could not create travel report")
+		return
+	}
+
 	if err := p.tmplAdmin.Execute(c.Writer, gin.H{
-		"metadata":     metadata,
-		"table":        table,
-		"status":       status,
-		"translations": translations,
+		"metadata":      metadata,
+		"table":         table,
+		"invitations":   invitations,
+		"status":        status,
+		"translations":  translations,
+		"missingTexts":  missingTexts,
+		"flags":         flags,
+		"maxFlagSizeKB": maxFlagSize >> 10,
+		"reference":     model.FallbackLanguage,
+		"questions":     adminQuestions(metadata, langs),
+		"subEvents":     subEvents,
+		"seating":       seating,
+		"gifts":         adminGifts(metadata, table),
+		"travel":        travel,
+		"theme": gin.H{
+			"Theme":           cmp.Or(metadata.Theme, &model.Theme{}),
+			"Fonts":           model.ThemeFontNames,
+			"Preview":         p.newThemePreview(metadata.Theme, themeFormValues(metadata.Theme)),
+			"MaxBannerSizeMB": maxImageSize >> 20,
+			"MaxCSS":          model.MaxThemeCSS,
+		},
 	}); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "could not exec admin template")
@@ -248,7 +448,18 @@ func (p *GuestHandler) RenderForm(c *gin.Context) {
 		return
 	}
 
-	lang := c.Query("lang")
+	lang, err := guestLanguage(ctx, c, p.tStore)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list languages")
+		p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+		c.String(http.StatusInternalServerError, "could not list languages")
+		return
+	}
+	// NOTE: an empty "?lang" opens the flag picker, e.g. from the navigation.
+	if chosen, ok := c.GetQuery("lang"); ok && chosen == "" {
+		lang = ""
+	}
 	if lang == "" {
 		langs, err := p.tStore.ListLanguages(c)
 		if err != nil {
@@ -276,7 +487,7 @@ func (p *GuestHandler) RenderForm(c *gin.Context) {
 		return
 	}
 
-	translation, err := p.tStore.ByLanguage(c, lang)
+	translation, err := translationWithFallback(ctx, p.tStore, lang)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unknown target language")
@@ -284,6 +495,9 @@ func (p *GuestHandler) RenderForm(c *gin.Context) {
 		c.String(http.StatusBadRequest, "unknown target language")
 		return
 	}
+	if c.Query("lang") != "" {
+		rememberLanguage(c, lang)
+	}
 
 	var guests []*model.Guest
 	for _, in := range invite.GuestIDs {
@@ -306,17 +520,31 @@ func (p *GuestHandler) RenderForm(c *gin.Context) {
 		return
 	}
 
+	funcs := translationFuncs(lang, translation, metadata, guests)
+	tag := translation.Tag(lang)
+	loc := locale.For(tag)
+
+	// NOTE: the punctuated first names are kept for greetings written before
+	// the firstnames function.
+	separator := strings.TrimSpace(loc.ListSeparator)
+	if separator == "" {
+		separator = ","
+	}
+	and := translation.And
+	if strings.TrimSpace(and) == "" {
+		and = loc.And
+	}
 	guestsGreetList := make([]struct{ Firstname string }, len(guests))
 	for index, guest := range guests {
 		guestsGreetList[index].Firstname = guest.Firstname
 		if index < len(guests)-2 {
-			guestsGreetList[index].Firstname = fmt.Sprintf("%s,", guest.Firstname)
+			guestsGreetList[index].Firstname = guest.Firstname + separator
 		} else if index < len(guests)-1 {
-			guestsGreetList[index].Firstname = fmt.Sprintf("%s %s", guest.Firstname, translation.And)
+			guestsGreetList[index].Firstname = fmt.Sprintf("%s %s", guest.Firstname, and)
 		}
 	}
 
-	translation.Greeting, err = evalTemplate(translation.Greeting, guestsGreetList)
+	translation.Greeting, err = evalTemplate(translation.Greeting, guestsGreetList, funcs)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "could not render greeting")
@@ -325,22 +553,22 @@ func (p *GuestHandler) RenderForm(c *gin.Context) {
 		return
 	}
 
-	cetLocation, err := time.LoadLocation("CET")
-	if err != nil {
-		panic(err)
-	}
+	partyDate := metadata.Date.In(metadata.TimeZone())
+	partyTime := loc.Format(partyDate, loc.TimeLayout+" MST")
 
 	helper := map[string]any{
 		"newline":      "<br />",
 		"bolt":         "<b>",
 		"boltend":      "</b>",
 		"locationname": metadata.Name,
-		"partytimeCET": metadata.Date.In(cetLocation).Format("15:04 MST"),
-		"partydate":    metadata.Date.In(cetLocation).Format("2. January"),
+		"partytime":    partyTime,
+		// NOTE: partytimeCET is kept for existing welcome messages.
+		"partytimeCET": partyTime,
+		"partydate":    loc.Format(partyDate, loc.DayMonthLayout),
 		"isSingular":   len(guests) == 1,
 	}
 
-	translation.WelcomeMessage, err = evalTemplateUnsafe(translation.WelcomeMessage, helper)
+	translation.WelcomeMessage, err = evalTemplate(translation.WelcomeMessage, helper, funcs)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "could not render welcome message")
@@ -349,11 +577,56 @@ func (p *GuestHandler) RenderForm(c *gin.Context) {
 		return
 	}
 
+	var plusOnes int
+	for _, g := range guests {
+		if g.Deleteable {
+			plusOnes++
+		}
+	}
+
+	themeCSS, banner := themeURLs(metadata.Theme)
+	var bannerSrcset string
+	if !metadata.Theme.IsZero() {
+		bannerSrcset = imageSrcset(metadata.Theme.BannerImage)
+	}
+
+	var deadline time.Time
+	if v, ok := c.Get(DeadlineKey); ok {
+		deadline, _ = v.(time.Time)
+	}
+	readOnly := !deadline.IsZero() && deadline.Before(time.Now())
+	var deadlineText string
+	if !deadline.IsZero() {
+		deadlineText = loc.Format(deadline.In(metadata.TimeZone()), loc.DateTimeLayout())
+	}
+
 	if err := p.tmplForm.Execute(c.Writer, gin.H{
-		"id":          id,
-		"metadata":    metadata,
-		"translation": translation,
-		"guests":      guests,
+		"id":                id,
+		"lang":              lang,
+		"locale":            tag,
+		"dir":               textDirection(translation, lang),
+		"flag":              flagURL(translation.FlagImgSrc),
+		"metadata":          metadata,
+		"partyDate":         loc.Format(partyDate, loc.DateTimeLayout()),
+		"deadlineText":      deadlineText,
+		"translation":       translation,
+		"guests":            guests,
+		"guestLimit":        invite.GuestLimit(),
+		"canAddGuest":       invite.CanAddPlusOne(plusOnes) == nil,
+		"ageOptions":        ageOptions(translation, true),
+		"plusOneAgeOptions": ageOptions(translation, invite.Children.Allowed()),
+		"deadline":          deadline,
+		"readOnly":          readOnly,
+		"dietaryTagOptions": choiceOptions(metadata.DietaryTagOptions(), translation.GuestForm.OptionsDietaryTags),
+		"allergenOptions":   choiceOptions(metadata.AllergenOptions(), translation.GuestForm.OptionsAllergens),
+		"questions":         guestQuestions(metadata, lang),
+		"subEvents":         guestSubEvents(metadata.SubEventsFor(invite.ID), lang, loc, metadata.TimeZone()),
+		"gifts":             guestGifts(metadata, invite.ID),
+		"travel":            newTravelOptions(metadata),
+		"themeCSS":          themeCSS,
+		"banner":            banner,
+		"bannerSrcset":      bannerSrcset,
+		"map":               newEventMap(metadata, translation),
 	}); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "could exec form template")
@@ -377,18 +650,55 @@ func (p *GuestHandler) Submit(c *gin.Context) {
 		return
 	}
 
+	inviteID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid inviteID")
+		p.logger.ErrorContext(ctx, "invalid inviteID", "error", err)
+		c.String(http.StatusBadRequest, "invalid inviteID")
+		return
+	}
+
+	invite, err := p.iStore.GetInvitationByID(ctx, inviteID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invite not found")
+		p.logger.WarnContext(ctx, "invite not found", "error", err)
+		c.String(http.StatusNotFound, "invite not found")
+		return
+	}
+
+	metadata, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	p.capacityMu.Lock()
+	defer p.capacityMu.Unlock()
+
+	var changes []statusChange
 	for id, attrs := range p.parseForm(c.Request.PostForm) {
 		guestID, err := uuid.Parse(id)
 		if err != nil {
 			span.AddEvent("invalid guest ID")
 			continue
 		}
+		if !invite.HasGuest(guestID) {
+			span.AddEvent("guest does not belong to invitation")
+			continue
+		}
 		guest, err := p.gStore.GetGuestByID(ctx, guestID)
 		if err != nil {
 			span.AddEvent("could not load guest")
 			continue
 		}
 
+		previous := guest.InvitationStatus
+		previousSubEvents := maps.Clone(guest.SubEvents)
 		if err := form.Unmarshal(attrs, guest); err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "could not unmarshal guest")
@@ -397,7 +707,73 @@ func (p *GuestHandler) Submit(c *gin.Context) {
 			return
 		}
 
-		if err := p.gStore.UpdateGuest(ctx, guest); err != nil {
+		if !model.GuestMaySetStatus(previous, guest.InvitationStatus) {
+			err := errors.New("guests may not set this status")
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "invalid invitation status")
+			p.logger.WarnContext(ctx, "invalid invitation status", "error", err, "id", guest.ID.String())
+			c.String(http.StatusBadRequest, "invalid invitation status")
+			return
+		}
+
+		if err := parseTravelTimes(attrs, &guest.Travel, metadata.TimeZone()); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not parse travel times")
+			p.logger.WarnContext(ctx, "could not parse travel times", "error", err, "id", guest.ID.String())
+			NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonTravel)
+			return
+		}
+		guest.Travel, err = metadata.CheckTravel(guest.Travel)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "invalid travel plans")
+			p.logger.WarnContext(ctx, "invalid travel plans", "error", err, "id", guest.ID.String())
+			NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonTravel)
+			return
+		}
+
+		guest.DietaryTags = keepChoices(guest.DietaryTags, metadata.DietaryTagOptions())
+		guest.Allergens = keepChoices(guest.Allergens, metadata.AllergenOptions())
+
+		if err := invite.CheckGuest(guest); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "guest violates invitation policy")
+			p.logger.WarnContext(ctx, "guest violates invitation policy", "error", err, "id", guest.ID.String())
+			NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonPolicy)
+			return
+		}
+
+		guest.Answers, err = metadata.CheckAnswers(guest)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "invalid answers")
+			p.logger.WarnContext(ctx, "invalid answers", "error", err, "id", guest.ID.String())
+			NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonAnswer)
+			return
+		}
+		guest.SubEvents = keepSubEvents(guest.SubEvents, metadata.SubEventsFor(invite.ID))
+		changes = append(changes, statusChange{guest: guest, previous: previous, previousSubEvents: previousSubEvents})
+	}
+
+	if err := p.checkSubEvents(ctx, metadata, changes); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "sub-event is fully booked")
+		p.logger.WarnContext(ctx, "sub-event is fully booked", "error", err)
+		NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonFullyBooked)
+		return
+	}
+
+	waitlisted, err := p.applyCapacity(ctx, metadata, changes)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not apply event capacity")
+		p.logger.ErrorContext(ctx, "could not apply event capacity", "error", err)
+		NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonProcess)
+		return
+	}
+
+	for _, ch := range changes {
+		if err := p.gStore.UpdateGuest(ctx, ch.guest); err != nil {
 			p.logger.ErrorContext(ctx, "could update guest", "error", err)
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "could update guest")
@@ -405,8 +781,17 @@ func (p *GuestHandler) Submit(c *gin.Context) {
 		}
 	}
 
-	lang := c.Query("lang")
-	translation, err := p.tStore.ByLanguage(c, lang)
+	if err := p.promoteWaitlist(ctx, metadata); err != nil {
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "could not promote waitlist", "error", err)
+	}
+
+	lang, err := guestLanguage(ctx, c, p.tStore)
+	if err != nil {
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+	}
+	translation, err := translationWithFallback(ctx, p.tStore, lang)
 	if err != nil {
 		p.logger.ErrorContext(ctx, "unknown target language", "error", err)
 		c.String(http.StatusBadRequest, "unknown target language")
@@ -422,9 +807,14 @@ func (p *GuestHandler) Submit(c *gin.Context) {
 		return
 	}
 
+	message := translation.GuestForm.MessageSubmitSuccess
+	if waitlisted {
+		message = translation.GuestForm.MessageWaitlisted
+	}
+
 	err = t.Execute(c.Writer, gin.H{
 		"Title":   translation.Success.Title,
-		"Message": translation.GuestForm.MessageSubmitSuccess,
+		"Message": message,
 	})
 	if err != nil {
 		span.RecordError(err)
@@ -440,8 +830,12 @@ func (p *ErrorHandler) Handle(c *gin.Context, reason model.ErrorReason) {
 	ctx, span = tracer.Start(ctx, "ErrorHandler.Handle")
 	defer span.End()
 
-	lang := c.Query("lang")
-	translation, err := p.tStore.ByLanguage(c, lang)
+	lang, err := guestLanguage(ctx, c, p.tStore)
+	if err != nil {
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+	}
+	translation, err := translationWithFallback(ctx, p.tStore, lang)
 	if err != nil {
 		p.logger.ErrorContext(ctx, "unknown target language", "error", err)
 		c.String(http.StatusBadRequest, "unknown target language")
@@ -464,10 +858,24 @@ func (p *ErrorHandler) Handle(c *gin.Context, reason model.ErrorReason) {
 		message = translation.Error.Deadline
 	case model.ErrorReasonProcess:
 		message = translation.Error.Process
+	case model.ErrorReasonPolicy:
+		message = translation.Error.Policy
+	case model.ErrorReasonAnswer:
+		message = translation.Error.Answer
+	case model.ErrorReasonFullyBooked:
+		message = translation.Error.FullyBooked
+	case model.ErrorReasonGiftUnavailable:
+		message = translation.Error.GiftUnavailable
+	case model.ErrorReasonTravel:
+		message = translation.Error.Travel
 	default:
 		message = translation.Error.Process
 	}
 
+	// NOTE: errors are always shown as toast, even if the request targets
+	// another element.
+	c.Header("HX-Retarget", "#toast-container")
+	c.Header("HX-Reswap", "afterbegin")
 	err = t.Execute(c.Writer, gin.H{
 		"Title":   translation.Error.Title,
 		"Message": message,
@@ -485,7 +893,7 @@ func (p *ErrorHandler) Handle(c *gin.Context, reason model.ErrorReason) {
 func (p *GuestHandler) parseForm(raw url.Values) map[string]url.Values {
 	input := make(map[string]url.Values)
 	for k, v := range raw {
-		got := strings.Split(k, ".")
+		got := strings.SplitN(k, ".", 2)
 		if len(got) < 2 {
 			continue
 		}
@@ -506,6 +914,15 @@ func (p *GuestHandler) CreateInvitation(c *gin.Context) {
 	ctx, span = tracer.Start(ctx, "GuestHandler.CreateInvitation")
 	defer span.End()
 
+	metadata, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
 	invs, err := p.iStore.ListInvitations(ctx)
 	if err != nil {
 		span.RecordError(err)
@@ -514,7 +931,7 @@ func (p *GuestHandler) CreateInvitation(c *gin.Context) {
 		c.String(http.StatusInternalServerError, "could not list invitations")
 		return
 	}
-	if len(invs) >= 250 { // HACK
+	if len(invs) >= metadata.InvitationLimit() {
 		err := errors.New("maximum number of invitations exceeded")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "can not add more invitations to this event")
@@ -543,7 +960,7 @@ func (p *GuestHandler) CreateInvitation(c *gin.Context) {
 	}
 
 	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_TABLE_INVITATION_ROW\" .}}")
-	t, err := wrapperTemplate.ParseFS(templates, "admin.invitation-table-row.html")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.invitation-table-row.html", "admin.invitation-limits.html")
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to parse invitation-table-row template")
@@ -552,7 +969,8 @@ func (p *GuestHandler) CreateInvitation(c *gin.Context) {
 	}
 
 	err = t.Execute(c.Writer, gin.H{
-		"inviteId": invite.ID.String(),
+		"inviteId":   invite.ID.String(),
+		"invitation": invite,
 	})
 	if err != nil {
 		span.RecordError(err)
@@ -579,6 +997,9 @@ func (p *GuestHandler) Create(c *gin.Context) {
 			return
 		}
 
+		p.inviteMu.Lock()
+		defer p.inviteMu.Unlock()
+
 		invite, err := p.iStore.GetInvitationByID(ctx, inviteID)
 		if err != nil {
 			span.RecordError(err)
@@ -588,8 +1009,7 @@ func (p *GuestHandler) Create(c *gin.Context) {
 			return
 		}
 
-		if len(invite.GuestIDs) >= 10 { // HACK
-			err := errors.New("maximum number of guests exceeded")
+		if err := invite.CanAddPlusOne(p.countPlusOnes(ctx, invite)); err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "can not add more guests to invite")
 			p.logger.ErrorContext(ctx, "can not add more guests to invite", "error", err)
@@ -615,7 +1035,16 @@ func (p *GuestHandler) Create(c *gin.Context) {
 		}
 
 		span.AddEvent("render guest input block")
-		p.renderGuestInputBlock(ctx, c.Writer, c.DefaultQuery("lang", "en"), invite.ID, gID)
+		lang, err := guestLanguage(ctx, c, p.tStore)
+		if err != nil {
+			span.RecordError(err)
+			p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+		}
+		p.renderGuestInputBlock(ctx, c.Writer, lang, invite, gID)
+		if invite.CanAddPlusOne(p.countPlusOnes(ctx, invite)) != nil {
+			span.AddEvent("hide add guest button")
+			_, _ = c.Writer.WriteString(`<div id="guest-form__button-add__container" hx-swap-oob="outerHTML" class="hidden"></div>`)
+		}
 		return
 	}
 
@@ -663,6 +1092,9 @@ func (p *GuestHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	p.inviteMu.Lock()
+	defer p.inviteMu.Unlock()
+
 	invite, err := p.iStore.GetInvitationByID(ctx, inviteID)
 	if err != nil {
 		span.RecordError(err)
@@ -689,6 +1121,17 @@ func (p *GuestHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	if guest.InvitationStatus == model.InvitationStatusAccepted {
+		p.capacityMu.Lock()
+		defer p.capacityMu.Unlock()
+		if metadata, err := p.eStore.GetEvent(ctx); err == nil {
+			if err := p.promoteWaitlist(ctx, metadata); err != nil {
+				span.RecordError(err)
+				p.logger.ErrorContext(ctx, "could not promote waitlist", "error", err)
+			}
+		}
+	}
+
 	c.Status(http.StatusAccepted)
 }
 
@@ -705,26 +1148,179 @@ func (p *GuestHandler) Update(c *gin.Context) {
 	// c.String(http.StatusOK, "user update successful")
 }
 
-func (p *GuestHandler) renderGuestInputBlock(ctx context.Context, w gin.ResponseWriter, lang string, iID, gID uuid.UUID) {
+func (p *GuestHandler) UpdateInvitation(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UpdateInvitation")
+	defer span.End()
+
+	inviteID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid invite ID")
+		p.logger.ErrorContext(ctx, "invalid invite ID", "error", err)
+		c.String(http.StatusBadRequest, "invalid invite ID")
+		return
+	}
+
+	p.inviteMu.Lock()
+	defer p.inviteMu.Unlock()
+
+	invite, err := p.iStore.GetInvitationByID(ctx, inviteID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invite not found")
+		p.logger.WarnContext(ctx, "invite not found", "error", err)
+		c.String(http.StatusNotFound, "invite not found")
+		return
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		c.String(http.StatusBadRequest, "could not parse form")
+		return
+	}
+
+	if err := form.Unmarshal(c.Request.PostForm, invite); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse invitation")
+		p.logger.ErrorContext(ctx, "could not parse invitation", "error", err)
+		c.String(http.StatusBadRequest, "could not parse invitation")
+		return
+	}
+	// NOTE: the form parser skips empty numbers, but a cleared limit resets
+	// the invitation to the default.
+	for name, limit := range map[string]*int{"max_guests": &invite.MaxGuests, "max_plus_ones": &invite.MaxPlusOnes} {
+		if v, ok := c.Request.PostForm[name]; ok && len(v) == 1 && v[0] == "" {
+			*limit = 0
+		}
+	}
+
+	invite.DefaultLanguage = strings.TrimSpace(invite.DefaultLanguage)
+	if invite.DefaultLanguage != "" {
+		langs, err := p.tStore.ListLanguages(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not list languages")
+			p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+			c.String(http.StatusInternalServerError, "could not list languages")
+			return
+		}
+		if !slices.Contains(langs, invite.DefaultLanguage) {
+			err := fmt.Errorf("unknown language %q", invite.DefaultLanguage)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if extension, ok := c.Request.PostForm["deadline_extension"]; ok && len(extension) == 1 {
+		event, err := p.eStore.GetEvent(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not find event")
+			p.logger.ErrorContext(ctx, "could not find event", "error", err)
+			c.String(http.StatusInternalServerError, "could not find event")
+			return
+		}
+		ts, err := parseOptionalDate(extension[0], event.TimeZone())
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not parse deadline extension")
+			p.logger.ErrorContext(ctx, "could not parse deadline extension", "error", err)
+			c.String(http.StatusBadRequest, "could not parse deadline extension")
+			return
+		}
+		invite.DeadlineExtension = ts
+	}
+
+	if err := p.iStore.UpdateInvitation(ctx, invite); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to update invitation")
+		p.logger.WarnContext(ctx, "unable to update invitation", "error", err)
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// parseDate parses an ISO 8601 date entered in the admin area. Dates without
+// a UTC offset, like those of datetime-local inputs, are in the given time
+// zone.
+func parseDate(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts.In(loc), nil
+	}
+	for _, layout := range []string{dateInputLayout, "2006-01-02T15:04:05"} {
+		if ts, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected ISO 8601, e.g. 2024-05-01T18:00", value)
+}
+
+// parseOptionalDate parses a date entered in the admin area. An empty value
+// clears the date.
+func parseOptionalDate(value string, loc *time.Location) (*time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	ts, err := parseDate(value, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &ts, nil
+}
+
+// countPlusOnes returns the number of guests of an invitation that were added
+// by the invited guests themselves.
+func (p *GuestHandler) countPlusOnes(ctx context.Context, invite *model.Invitation) int {
+	var plusOnes int
+	for _, gID := range invite.GuestIDs {
+		guest, err := p.gStore.GetGuestByID(ctx, gID)
+		if err != nil {
+			p.logger.WarnContext(ctx, "could not read guest", "error", err, "id", gID.String())
+			continue
+		}
+		if guest.Deleteable {
+			plusOnes++
+		}
+	}
+	return plusOnes
+}
+
+func (p *GuestHandler) renderGuestInputBlock(ctx context.Context, w gin.ResponseWriter, lang string, invite *model.Invitation, gID uuid.UUID) {
 	var span trace.Span
 	ctx, span = tracer.Start(ctx, "GuestHandler.renderGuestInputBlock")
 	defer span.End()
 
-	translation, err := p.tStore.ByLanguage(ctx, lang)
+	translation, err := translationWithFallback(ctx, p.tStore, lang)
 	if err != nil {
 		msg := "could not determine target language"
 		span.AddEvent(msg)
 		p.logger.WarnContext(ctx, msg, "error", err)
 	}
 
-	// TODO: Some options
-	// - remove the wrapperTemplate, directly render guest-input and remove the define from guest-put
-	// - make it possible to use the guest-input within the guest-form inside the guest-loop
-	//	- this currently fails because without https://gohugo.io/functions/dict/ it seems it is not possible to pass both the $root data and the $guest data (".") to the template
-	//	- missing $.translation data
-	//	- https://stackoverflow.com/questions/18276173/calling-a-template-with-several-pipeline-parameters
-	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"GUEST_INPUT\" .}}")
-	t, err := wrapperTemplate.ParseFS(templates, "guest-input.html")
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		msg := "could not find event"
+		span.AddEvent(msg)
+		p.logger.WarnContext(ctx, msg, "error", err)
+		event = &model.Event{}
+	}
+
+	var dietaryTagLabels, allergenLabels map[string]string
+	if translation != nil {
+		dietaryTagLabels = translation.GuestForm.OptionsDietaryTags
+		allergenLabels = translation.GuestForm.OptionsAllergens
+	}
+
+	wrapperTemplate, _ := template.New("wrapper").Funcs(invitationFuncs).Parse("{{ template \"GUEST_INPUT\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "guest-input.html", "guest-fields.html")
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to parse guest input template")
@@ -733,9 +1329,15 @@ func (p *GuestHandler) renderGuestInputBlock(ctx context.Context, w gin.Response
 	}
 
 	err = t.Execute(w, gin.H{
-		"invitationID": iID,
-		"ID":           gID,
-		"translation":  translation,
+		"id":                invite.ID,
+		"guest":             &model.Guest{ID: gID, Deleteable: true},
+		"translation":       translation,
+		"plusOneAgeOptions": ageOptions(translation, invite.Children.Allowed()),
+		"dietaryTagOptions": choiceOptions(event.DietaryTagOptions(), dietaryTagLabels),
+		"allergenOptions":   choiceOptions(event.AllergenOptions(), allergenLabels),
+		"questions":         guestQuestions(event, lang),
+		"subEvents":         guestSubEvents(event.SubEventsFor(invite.ID), lang, locale.For(translation.Tag(lang)), event.TimeZone()),
+		"travel":            newTravelOptions(event),
 	})
 	if err != nil {
 		span.RecordError(err)
@@ -745,11 +1347,80 @@ func (p *GuestHandler) renderGuestInputBlock(ctx context.Context, w gin.Response
 	}
 }
 
+type ageOption struct {
+	Value model.GuestAgeCategory
+	Label string
+}
+
+// ageOptions lists the selectable age categories, leaving out the unknown
+// category and, if not allowed, the categories of children.
+func ageOptions(translation *model.Translation, childrenAllowed bool) []ageOption {
+	if translation == nil {
+		return nil
+	}
+	var res []ageOption
+	for i, label := range translation.GuestForm.SelectOptionsAge {
+		age := model.GuestAgeCategory(i)
+		if age == model.GuestAgeCategoryUnknown || (age.IsChild() && !childrenAllowed) {
+			continue
+		}
+		res = append(res, ageOption{Value: age, Label: label})
+	}
+	return res
+}
+
+type choiceOption struct {
+	Value string
+	Label string
+}
+
+// choiceOptions pairs the given choices with their translated labels. Choices
+// without a translation are labeled with their value.
+func choiceOptions(choices []string, labels map[string]string) []choiceOption {
+	res := make([]choiceOption, 0, len(choices))
+	for _, choice := range choices {
+		label := labels[choice]
+		if label == "" {
+			label = choice
+		}
+		res = append(res, choiceOption{Value: choice, Label: label})
+	}
+	return res
+}
+
+// keepChoices drops selected values that are not among the offered choices.
+func keepChoices(selected, choices []string) []string {
+	var res []string
+	for _, s := range selected {
+		if slices.Contains(choices, s) && !slices.Contains(res, s) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// splitChoices parses a comma-separated list of choices as entered in the
+// admin area.
+func splitChoices(value string) []string {
+	var res []string
+	for _, choice := range strings.Split(value, ",") {
+		choice = strings.ToLower(strings.TrimSpace(choice))
+		if choice != "" && !slices.Contains(res, choice) {
+			res = append(res, choice)
+		}
+	}
+	return res
+}
+
 func (p *GuestHandler) CreateAirport(c *gin.Context) {
 	var span trace.Span
 	ctx := c.Request.Context()
 	ctx, span = tracer.Start(ctx, "GuestHandler.CreateAirport")
 	defer span.End()
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
 	e, err := p.eStore.GetEvent(ctx)
 	if err != nil {
 		span.RecordError(err)
@@ -763,8 +1434,8 @@ func (p *GuestHandler) CreateAirport(c *gin.Context) {
 		span.SetStatus(codes.Error, err.Error())
 	}
 
-	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_EVENT_LOCATION_AIRPORT\" .airport}}")
-	t, err := wrapperTemplate.ParseFS(templates, "admin.event.location.html", "admin.event.location.airport.html")
+	wrapperTemplate, _ := template.New("wrapper").Funcs(mediaFuncs).Parse("{{ template \"ADMIN_EVENT_LOCATION_AIRPORT\" .airport}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.event.location.html", "admin.location.photos.html", "admin.event.location.airport.html")
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to parse invitation-table-row template")
@@ -795,6 +1466,10 @@ func (p *GuestHandler) DeleteAirport(c *gin.Context) {
 		span.SetStatus(codes.Error, err.Error())
 		return
 	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
 	e, err := p.eStore.GetEvent(ctx)
 	if err != nil {
 		span.RecordError(err)
@@ -820,6 +1495,10 @@ func (p *GuestHandler) CreateHotel(c *gin.Context) {
 	ctx := c.Request.Context()
 	ctx, span = tracer.Start(ctx, "GuestHandler.CreateHotel")
 	defer span.End()
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
 	e, err := p.eStore.GetEvent(ctx)
 	if err != nil {
 		span.RecordError(err)
@@ -833,8 +1512,8 @@ func (p *GuestHandler) CreateHotel(c *gin.Context) {
 		span.SetStatus(codes.Error, err.Error())
 	}
 
-	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_EVENT_LOCATION_HOTEL\" .hotel}}")
-	t, err := wrapperTemplate.ParseFS(templates, "admin.event.location.html", "admin.event.location.hotel.html")
+	wrapperTemplate, _ := template.New("wrapper").Funcs(mediaFuncs).Parse("{{ template \"ADMIN_EVENT_LOCATION_HOTEL\" .hotel}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.event.location.html", "admin.location.photos.html", "admin.event.location.hotel.html")
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unable to parse invitation-table-row template")
@@ -865,6 +1544,10 @@ func (p *GuestHandler) DeleteHotel(c *gin.Context) {
 		span.SetStatus(codes.Error, err.Error())
 		return
 	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
 	e, err := p.eStore.GetEvent(ctx)
 	if err != nil {
 		span.RecordError(err)
@@ -890,18 +1573,32 @@ func (p *GuestHandler) UpdateEvent(c *gin.Context) {
 	ctx := c.Request.Context()
 	ctx, span = tracer.Start(ctx, "GuestHandler.UpdateEvent")
 	defer span.End()
-	e, err := p.eStore.GetEvent(ctx)
+
+	if err := c.Request.ParseForm(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		c.String(http.StatusBadRequest, "could not parse form")
+		return
+	}
+
+	// NOTE: the addresses are geocoded before the event is locked, as the
+	// lookups may take seconds. The event is read again afterwards.
+	snapshot, err := p.eStore.GetEvent(ctx)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "could not get event")
 		return
 	}
+	geocoded := p.geocodeForm(ctx, snapshot, p.parseForm(c.Request.PostForm))
 
-	if err := c.Request.ParseForm(); err != nil {
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "could not parse form")
-		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
-		c.String(http.StatusBadRequest, "could not parse form")
+		span.SetStatus(codes.Error, "could not get event")
 		return
 	}
 
@@ -915,10 +1612,22 @@ func (p *GuestHandler) UpdateEvent(c *gin.Context) {
 		}
 	}
 
-	const layout = "2006-01-02 15:04:05 -0700 MST"
+	// NOTE: the time zone is updated first, as dates are entered in it.
+	if tz, ok := eventData["timezone"]; ok && len(tz) == 1 {
+		name := strings.TrimSpace(tz[0])
+		if _, err := model.LoadTimezone(name); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "invalid event time zone")
+			p.logger.ErrorContext(ctx, "invalid event time zone", "error", err)
+			p.adminError(c, fmt.Sprintf("%q is not a valid time zone.", name))
+			return
+		}
+		e.Timezone = name
+	}
+
 	dateStr, ok := eventData["date"]
 	if ok && len(dateStr) == 1 {
-		ts, err := time.Parse(layout, dateStr[0])
+		ts, err := parseDate(dateStr[0], e.TimeZone())
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "could not event date timestamp")
@@ -929,6 +1638,26 @@ func (p *GuestHandler) UpdateEvent(c *gin.Context) {
 		e.Date = ts
 	}
 
+	if tags, ok := eventData["dietary_tags"]; ok && len(tags) == 1 {
+		e.DietaryTags = splitChoices(tags[0])
+	}
+	if allergens, ok := eventData["allergens"]; ok && len(allergens) == 1 {
+		e.Allergens = splitChoices(allergens[0])
+	}
+
+	deadlineStr, ok := eventData["deadline"]
+	if ok && len(deadlineStr) == 1 {
+		ts, err := parseOptionalDate(deadlineStr[0], e.TimeZone())
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not parse event deadline")
+			p.logger.ErrorContext(ctx, "could not parse event deadline", "error", err)
+			c.String(http.StatusBadRequest, "could not parse event deadline")
+			return
+		}
+		e.Deadline = ts
+	}
+
 	if err := form.Unmarshal(eventData, e); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "could not parse event")
@@ -936,16 +1665,25 @@ func (p *GuestHandler) UpdateEvent(c *gin.Context) {
 		c.String(http.StatusBadRequest, "could not parse event")
 		return
 	}
-
-	for id, ldata := range raw {
-		ldata["id"] = []string{id}
-		l := model.Location{}
-		if err := form.Unmarshal(ldata, &l); err != nil {
+	var notes []*geocodeNote
+	if e.Location != nil {
+		// NOTE: the venue is embedded without form tag, so its fields are
+		// parsed separately, into a copy like the other locations.
+		venue := *e.Location
+		if err := form.Unmarshal(eventData, &venue); err != nil {
 			span.RecordError(err)
-			span.SetStatus(codes.Error, "could not parse other location")
-			p.logger.ErrorContext(ctx, "could not parse other location", "error", err)
-			continue
+			span.SetStatus(codes.Error, "could not parse venue")
+			p.logger.ErrorContext(ctx, "could not parse venue", "error", err)
+			c.String(http.StatusBadRequest, "could not parse venue")
+			return
 		}
+		if note := applyGeocodeNote(geocoded[e.ID], &venue); note != nil {
+			notes = append(notes, note)
+		}
+		e.Location = &venue
+	}
+
+	for id, ldata := range raw {
 		lID, err := uuid.Parse(id)
 		if err != nil {
 			span.RecordError(err)
@@ -953,14 +1691,24 @@ func (p *GuestHandler) UpdateEvent(c *gin.Context) {
 			p.logger.ErrorContext(ctx, "invalid uuid", "error", err)
 			continue
 		}
-		for i := 0; i < len(e.Airports); i++ {
-			if lID == e.Airports[i].ID {
-				e.Airports[i] = &l
-			}
-		}
-		for i := 0; i < len(e.Hotels); i++ {
-			if lID == e.Hotels[i].ID {
-				e.Hotels[i] = &l
+		// NOTE: update a copy of the existing location, so its ID stays the
+		// same. Guests refer to hotels and airports by ID.
+		for _, locations := range [][]*model.Location{e.Airports, e.Hotels} {
+			for i := 0; i < len(locations); i++ {
+				if lID != locations[i].ID {
+					continue
+				}
+				l := *locations[i]
+				if err := form.Unmarshal(ldata, &l); err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, "could not parse other location")
+					p.logger.ErrorContext(ctx, "could not parse other location", "error", err)
+					continue
+				}
+				if note := applyGeocodeNote(geocoded[lID], &l); note != nil {
+					notes = append(notes, note)
+				}
+				locations[i] = &l
 			}
 		}
 	}
@@ -968,15 +1716,37 @@ func (p *GuestHandler) UpdateEvent(c *gin.Context) {
 	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "could not update event")
+		return
 	}
+
+	p.capacityMu.Lock()
+	defer p.capacityMu.Unlock()
+	if err := p.promoteWaitlist(ctx, e); err != nil {
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "could not promote waitlist", "error", err)
+	}
+
+	p.renderGeocodeNotes(c, notes)
 }
 
 type TranslationHandler struct {
 	tStore db.TranslationStore
+	iStore db.InvitationStore
+	// inviteMu is the invitation lock of the guest handler, as renaming or
+	// deleting a language changes the default language of invitations.
+	inviteMu *sync.Mutex
+	logger   *slog.Logger
 }
 
-func NewTranslationHandler(tStore db.TranslationStore) *TranslationHandler {
-	return &TranslationHandler{tStore: tStore}
+// NewTranslationHandler returns the handler of the languages. It shares the
+// invitations and their lock with the guest handler.
+func NewTranslationHandler(tStore db.TranslationStore, guests *GuestHandler) *TranslationHandler {
+	return &TranslationHandler{
+		tStore:   tStore,
+		iStore:   guests.iStore,
+		inviteMu: &guests.inviteMu,
+		logger:   slog.Default().WithGroup("http"),
+	}
 }
 
 func (t *TranslationHandler) UpdateLanguage(c *gin.Context) {
@@ -1057,7 +1827,7 @@ func (t *TranslationHandler) UpdateLanguage(c *gin.Context) {
 			return
 		}
 		if _, err := t.tStore.ByLanguage(ctx, language); err != nil {
-			err := fmt.Errorf("cannot fin language %q: %w", language, err)
+			err := fmt.Errorf("cannot find language %q: %w", language, err)
 			span.RecordError(err)
 			span.SetStatus(codes.Error, err.Error())
 			c.String(http.StatusBadRequest, err.Error())
@@ -1081,6 +1851,22 @@ func (t *TranslationHandler) UpdateLanguage(c *gin.Context) {
 			c.String(http.StatusBadRequest, err.Error())
 			return
 		}
+		t.WelcomeMessage = richtext.Sanitize(t.WelcomeMessage)
+		t.Locale = strings.TrimSpace(t.Locale)
+		t.Direction = strings.ToLower(strings.TrimSpace(t.Direction))
+		if err := t.CheckSettings(); err != nil {
+			err := fmt.Errorf("%s: %w", language, err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := checkTranslationTemplates(language, &t); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
 		translations[language] = &t
 	}
 
@@ -1094,8 +1880,10 @@ func (t *TranslationHandler) UpdateLanguage(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-func evalTemplate(msg string, data any) (string, error) {
-	t, err := template.New("tmp").Parse(msg)
+// evalTemplate executes a translation string. The result is plain text,
+// which the invitation templates escape, or rich text, which they sanitize.
+func evalTemplate(msg string, data any, funcs txttemplate.FuncMap) (string, error) {
+	t, err := parseTemplate(msg, funcs)
 	if err != nil {
 		return "", err
 	}
@@ -1106,15 +1894,6 @@ func evalTemplate(msg string, data any) (string, error) {
 	return buf.String(), nil
 }
 
-func evalTemplateUnsafe(msg string, data any) (string, error) {
-	// NOTE: workaround to allow html formatting
-	t, err := txttemplate.New("tmp").Parse(msg)
-	if err != nil {
-		return "", err
-	}
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
+func parseTemplate(msg string, funcs txttemplate.FuncMap) (*txttemplate.Template, error) {
+	return txttemplate.New("tmp").Funcs(funcs).Parse(msg)
 }
@@ -0,0 +1,53 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/quixsi/core/internal/db"
+	"github.com/quixsi/core/internal/model"
+)
+
+// languageCookie remembers the language a guest chose with the flag picker.
+const languageCookie = "lang"
+
+// languageCookieMaxAge is how long the chosen language is remembered, in
+// seconds.
+const languageCookieMaxAge = 365 * 24 * 60 * 60
+
+// guestLanguage returns the language shown to a guest. It is, in order, the
+// language requested with "?lang", the language the guest chose before, the
+// default language of the invitation, or the language negotiated from the
+// Accept-Language header. Remembered and default languages are skipped if
+// they no longer exist. The result is "" if no language fits, so the guest
+// has to choose one.
+func guestLanguage(ctx context.Context, c *gin.Context, tStore db.TranslationStore) (string, error) {
+	if lang := c.Query("lang"); lang != "" {
+		return lang, nil
+	}
+
+	langs, err := tStore.ListLanguages(ctx)
+	if err != nil {
+		return "", err
+	}
+	if lang, err := c.Cookie(languageCookie); err == nil && slices.Contains(langs, lang) {
+		return lang, nil
+	}
+	if lang := c.GetString(DefaultLanguageKey); lang != "" && slices.Contains(langs, lang) {
+		return lang, nil
+	}
+	return model.NegotiateLanguage(c.GetHeader("Accept-Language"), langs), nil
+}
+
+// rememberLanguage stores the language a guest chose in a cookie, so it is
+// kept for later visits and the requests of the page.
+func rememberLanguage(c *gin.Context, lang string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(languageCookie, lang, languageCookieMaxAge, "/", "", c.Request.TLS != nil, true)
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/staticmap"
+)
+
+// eventMap is the map of the venue, the hotels and the airports with its
+// legend.
+type eventMap struct {
+	SVG    template.HTML
+	Places []*mapPlace
+}
+
+// mapPlace is an entry of the legend of an eventMap.
+type mapPlace struct {
+	Number int
+	Name   string
+	// Kind is the translated kind of the place, e.g. "Hotel".
+	Kind  string
+	Color string
+	Links []mapLink
+}
+
+// mapLink opens a place in another app.
+type mapLink struct {
+	Name string
+	URL  template.URL
+}
+
+// newEventMap returns the map of the places of an event that have
+// coordinates, or nil if there are none.
+func newEventMap(e *model.Event, t *model.Translation) *eventMap {
+	type place struct {
+		location *model.Location
+		kind     staticmap.Kind
+		label    string
+	}
+	var places []place
+	if e.Location != nil {
+		venue := *e.Location
+		if venue.Name == "" {
+			venue.Name = e.Name
+		}
+		places = append(places, place{&venue, staticmap.Venue, t.Location.LegendVenue})
+	}
+	for _, h := range e.Hotels {
+		places = append(places, place{h, staticmap.Hotel, t.Location.LegendHotel})
+	}
+	for _, a := range e.Airports {
+		places = append(places, place{a, staticmap.Airport, t.Location.LegendAirport})
+	}
+
+	m := &eventMap{}
+	var markers []staticmap.Marker
+	for _, p := range places {
+		l := p.location
+		if !staticmap.Valid(l.Latitude, l.Longitude) {
+			continue
+		}
+		number := len(markers) + 1
+		markers = append(markers, staticmap.Marker{
+			Kind:      p.kind,
+			Label:     strconv.Itoa(number),
+			Title:     l.Name,
+			Latitude:  l.Latitude,
+			Longitude: l.Longitude,
+		})
+		m.Places = append(m.Places, &mapPlace{
+			Number: number,
+			Name:   l.Name,
+			Kind:   p.label,
+			Color:  p.kind.Color(),
+			Links:  mapLinks(l, t.Location.OpenInApp),
+		})
+	}
+	if len(markers) == 0 {
+		return nil
+	}
+	// NOTE: Render escapes all texts, the SVG is safe to embed.
+	m.SVG = template.HTML(staticmap.Render(t.Location.MapLabel, markers))
+	return m
+}
+
+// mapLinks returns links opening a location in the maps app of the device
+// and in common map websites. appLabel is the name of the link to the app.
+func mapLinks(l *model.Location, appLabel string) []mapLink {
+	lat := strconv.FormatFloat(l.Latitude, 'f', -1, 64)
+	lon := strconv.FormatFloat(l.Longitude, 'f', -1, 64)
+	name := url.QueryEscape(l.Name)
+	geoName := url.PathEscape(l.Name)
+	return []mapLink{
+		// NOTE: html/template rejects URLs with other schemes than http,
+		// https and mailto, the geo URI is built from numbers and an
+		// escaped name only.
+		{appLabel, template.URL(fmt.Sprintf("geo:%s,%s?q=%s,%s(%s)", lat, lon, lat, lon, geoName))},
+		{"OpenStreetMap", template.URL(fmt.Sprintf("https://www.openstreetmap.org/?mlat=%s&mlon=%s#map=16/%s/%s", lat, lon, lat, lon))},
+		{"Google Maps", template.URL(fmt.Sprintf("https://www.google.com/maps/search/?api=1&query=%s%%2C%s", lat, lon))},
+		{"Apple Maps", template.URL(fmt.Sprintf("https://maps.apple.com/?ll=%s,%s&q=%s", lat, lon, name))},
+	}
+}
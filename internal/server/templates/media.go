@@ -0,0 +1,279 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/blob"
+	"github.com/quixsi/core/internal/media"
+	"github.com/quixsi/core/internal/model"
+)
+
+// maxImageSize is the maximum size of an uploaded photo or banner in bytes.
+// They are scaled down, so photos can be uploaded as taken.
+const maxImageSize = 16 << 20
+
+// mediaURL returns the URL of a blob.
+func mediaURL(key string) string {
+	return "/media/" + key
+}
+
+// imageSrc returns the URL of the largest variant of an image, or "".
+func imageSrc(img *model.Image) string {
+	if v := img.Largest(); v != nil {
+		return mediaURL(v.Key)
+	}
+	return ""
+}
+
+// imageSrcset returns the variants of an image as value of a srcset
+// attribute, e.g. "/media/images/<id>/480.jpg 480w, ...".
+func imageSrcset(img *model.Image) string {
+	if img == nil {
+		return ""
+	}
+	res := make([]string, len(img.Variants))
+	for i, v := range img.Variants {
+		res[i] = fmt.Sprintf("%s %dw", mediaURL(v.Key), v.Width)
+	}
+	return strings.Join(res, ", ")
+}
+
+// mediaFuncs are the functions of templates showing images.
+var mediaFuncs = template.FuncMap{
+	"imageSrc":    imageSrc,
+	"imageSrcset": imageSrcset,
+}
+
+// eventLocation returns the venue, hotel or airport with the given ID.
+func eventLocation(e *model.Event, id uuid.UUID) *model.Location {
+	if e.Location != nil && e.ID == id {
+		return e.Location
+	}
+	for _, locations := range [][]*model.Location{e.Hotels, e.Airports} {
+		for _, l := range locations {
+			if l.ID == id {
+				return l
+			}
+		}
+	}
+	return nil
+}
+
+// readImage reads an uploaded image of the multipart form. It returns nil
+// without error if no file was uploaded.
+func readImage(c *gin.Context, name string) ([]byte, error) {
+	file, _, err := c.Request.FormFile(name)
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("the image is larger than %d MB", maxImageSize>>20)
+	}
+	return data, nil
+}
+
+// limitImageUpload limits the request body to an image and some form fields.
+func limitImageUpload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageSize+model.MaxThemeCSS+(16<<10))
+}
+
+// ServeMedia serves uploaded images. A key is never reused for other
+// content, so browsers may cache them forever.
+func (p *GuestHandler) ServeMedia(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ServeMedia")
+	defer span.End()
+
+	b, err := p.bStore.Get(ctx, strings.TrimPrefix(c.Param("key"), "/"))
+	if errors.Is(err, blob.ErrNotFound) || errors.Is(err, blob.ErrInvalidKey) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not read media")
+		p.logger.ErrorContext(ctx, "could not read media", "error", err)
+		c.String(http.StatusInternalServerError, "could not read media")
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Data(http.StatusOK, b.ContentType, b.Data)
+}
+
+// renderLocationPhotos renders the photos of a location in the admin area.
+func (p *GuestHandler) renderLocationPhotos(c *gin.Context, l *model.Location) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContext(ctx)
+
+	wrapperTemplate, _ := template.New("wrapper").Funcs(mediaFuncs).Parse("{{ template \"ADMIN_LOCATION_PHOTOS\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.location.photos.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse photos template")
+		p.logger.ErrorContext(ctx, "unable to parse photos template", "error", err)
+		return
+	}
+
+	if err := t.Execute(c.Writer, l); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute photos template")
+		p.logger.ErrorContext(ctx, "unable to execute photos template", "error", err)
+	}
+}
+
+// UploadPhoto adds a photo to the venue, a hotel or an airport.
+func (p *GuestHandler) UploadPhoto(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UploadPhoto")
+	defer span.End()
+
+	locationID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid location id")
+		c.String(http.StatusBadRequest, "invalid location id")
+		return
+	}
+
+	limitImageUpload(c)
+	data, err := readImage(c, "photo")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.adminError(c, fmt.Sprintf("Could not read the photo: %s.", err))
+		return
+	}
+	if data == nil {
+		c.String(http.StatusBadRequest, "missing photo")
+		return
+	}
+
+	img, err := media.Store(ctx, p.bStore, data)
+	if errors.Is(err, media.ErrUnsupported) || errors.Is(err, media.ErrTooLarge) {
+		p.adminError(c, fmt.Sprintf("The photo can not be used: %s.", err))
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not store photo")
+		p.logger.ErrorContext(ctx, "could not store photo", "error", err)
+		c.String(http.StatusInternalServerError, "could not store photo")
+		return
+	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		_ = media.Delete(ctx, p.bStore, img)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+	l := eventLocation(e, locationID)
+	if l == nil {
+		_ = media.Delete(ctx, p.bStore, img)
+		c.String(http.StatusNotFound, "unknown location")
+		return
+	}
+	l.Photos = append(l.Photos, img)
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		_ = media.Delete(ctx, p.bStore, img)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+
+	p.renderLocationPhotos(c, l)
+}
+
+// DeletePhoto removes a photo of the venue, a hotel or an airport.
+func (p *GuestHandler) DeletePhoto(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.DeletePhoto")
+	defer span.End()
+
+	locationID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid location id")
+		c.String(http.StatusBadRequest, "invalid location id")
+		return
+	}
+	photoID, err := uuid.Parse(c.Param("photoid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid photo id")
+		c.String(http.StatusBadRequest, "invalid photo id")
+		return
+	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+	l := eventLocation(e, locationID)
+	if l == nil {
+		c.String(http.StatusNotFound, "unknown location")
+		return
+	}
+	i := slices.IndexFunc(l.Photos, func(img *model.Image) bool { return img.ID == photoID })
+	if i < 0 {
+		c.String(http.StatusNotFound, "unknown photo")
+		return
+	}
+	img := l.Photos[i]
+	l.Photos = slices.Delete(l.Photos, i, i+1)
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+	// NOTE: the photo is gone from the event, a failed delete only leaves
+	// unused blobs behind.
+	if err := media.Delete(ctx, p.bStore, img); err != nil {
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "could not delete photo", "error", err)
+	}
+
+	p.renderLocationPhotos(c, l)
+}
@@ -0,0 +1,273 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/parser/form"
+)
+
+// guestQuestion is a question of the event as shown to guests in their
+// language.
+type guestQuestion struct {
+	ID       string
+	Type     model.QuestionType
+	Required bool
+	Label    string
+	Options  []choiceOption
+}
+
+func guestQuestions(event *model.Event, lang string) []guestQuestion {
+	res := make([]guestQuestion, 0, len(event.Questions))
+	for _, q := range event.Questions {
+		gq := guestQuestion{
+			ID:       q.ID.String(),
+			Type:     q.Type,
+			Required: q.Required,
+			Label:    q.Label(lang),
+		}
+		for _, o := range q.Options {
+			gq.Options = append(gq.Options, choiceOption{Value: o.Value, Label: o.Label(lang)})
+		}
+		res = append(res, gq)
+	}
+	return res
+}
+
+// adminQuestion is a question of the event together with the languages it
+// can be labeled in.
+type adminQuestion struct {
+	*model.Question
+	Languages []string
+}
+
+// OptionValues returns the values of the options, one per line.
+func (q adminQuestion) OptionValues() string {
+	values := make([]string, len(q.Options))
+	for i, o := range q.Options {
+		values[i] = o.Value
+	}
+	return strings.Join(values, "\n")
+}
+
+// OptionLabels returns the labels of the options in the given language, one
+// per line.
+func (q adminQuestion) OptionLabels(lang string) string {
+	labels := make([]string, len(q.Options))
+	for i, o := range q.Options {
+		labels[i] = o.Labels[lang]
+	}
+	return strings.Join(labels, "\n")
+}
+
+func adminQuestions(event *model.Event, langs []string) []adminQuestion {
+	langs = append([]string(nil), langs...)
+	sort.Strings(langs)
+	res := make([]adminQuestion, len(event.Questions))
+	for i, q := range event.Questions {
+		res[i] = adminQuestion{Question: q, Languages: langs}
+	}
+	return res
+}
+
+// splitLines splits the content of a textarea into its lines.
+func splitLines(value string) []string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.TrimRight(value, "\n")
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return strings.Split(value, "\n")
+}
+
+// parseQuestionOptions builds the options of a question from the option values
+// and their labels by language, both given one per line.
+func parseQuestionOptions(values string, labels map[string]string) []*model.QuestionOption {
+	labelsByLang := make(map[string][]string, len(labels))
+	for lang, l := range labels {
+		labelsByLang[lang] = splitLines(l)
+	}
+
+	var res []*model.QuestionOption
+	for i, value := range splitLines(values) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		o := &model.QuestionOption{Value: value, Labels: make(map[string]string)}
+		for lang, l := range labelsByLang {
+			if i < len(l) && strings.TrimSpace(l[i]) != "" {
+				o.Labels[lang] = strings.TrimSpace(l[i])
+			}
+		}
+		res = append(res, o)
+	}
+	return res
+}
+
+func (p *GuestHandler) CreateQuestion(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.CreateQuestion")
+	defer span.End()
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	langs, err := p.tStore.ListLanguages(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list languages")
+		p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+		c.String(http.StatusInternalServerError, "could not list languages")
+		return
+	}
+
+	question := &model.Question{ID: uuid.New(), Type: model.QuestionTypeText}
+	e.Questions = append(e.Questions, question)
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_EVENT_QUESTION\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.event.questions.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse question template")
+		p.logger.ErrorContext(ctx, "unable to parse question template", "error", err)
+		return
+	}
+
+	if err := t.Execute(c.Writer, adminQuestions(&model.Event{Questions: []*model.Question{question}}, langs)[0]); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute question template")
+		p.logger.ErrorContext(ctx, "unable to execute question template", "error", err)
+		return
+	}
+}
+
+func (p *GuestHandler) UpdateQuestions(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UpdateQuestions")
+	defer span.End()
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		c.String(http.StatusBadRequest, "could not parse form")
+		return
+	}
+
+	raw := p.parseForm(c.Request.PostForm)
+	for _, q := range e.Questions {
+		data, ok := raw[q.ID.String()]
+		if !ok {
+			continue
+		}
+		if err := form.Unmarshal(data, q); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not parse question")
+			p.logger.ErrorContext(ctx, "could not parse question", "error", err)
+			c.String(http.StatusBadRequest, "could not parse question")
+			return
+		}
+
+		var options struct {
+			Values string            `form:"options"`
+			Labels map[string]string `form:"option_labels"`
+		}
+		if err := form.Unmarshal(data, &options); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not parse question options")
+			p.logger.ErrorContext(ctx, "could not parse question options", "error", err)
+			c.String(http.StatusBadRequest, "could not parse question options")
+			return
+		}
+		q.Options = parseQuestionOptions(options.Values, options.Labels)
+	}
+
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (p *GuestHandler) DeleteQuestion(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.DeleteQuestion")
+	defer span.End()
+
+	questionID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid question ID")
+		return
+	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	for i := 0; i < len(e.Questions); i++ {
+		if e.Questions[i].ID == questionID {
+			e.Questions = append(e.Questions[:i], e.Questions[i+1:]...)
+			break
+		}
+	}
+
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
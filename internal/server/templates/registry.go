@@ -0,0 +1,348 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/parser/form"
+)
+
+// guestGift is a gift of the registry as shown to the guests of an
+// invitation. It does not reveal who claimed the other items.
+type guestGift struct {
+	ID        string
+	Title     string
+	Link      string
+	Price     string
+	Available int
+	Claimed   int
+}
+
+func guestGifts(event *model.Event, inviteID uuid.UUID) []guestGift {
+	res := make([]guestGift, len(event.Gifts))
+	for i, g := range event.Gifts {
+		res[i] = guestGift{
+			ID:        g.ID.String(),
+			Title:     g.Title,
+			Link:      g.Link,
+			Available: g.Available(),
+			Claimed:   g.ClaimedBy(inviteID),
+		}
+		if g.Price > 0 {
+			res[i].Price = fmt.Sprintf("%.2f", g.Price)
+		}
+	}
+	return res
+}
+
+// giftClaim lists the items of a gift claimed by an invitation.
+type giftClaim struct {
+	InvitationID uuid.UUID
+	Guests       string
+	Quantity     int
+}
+
+// adminGift is a gift of the registry together with its claims.
+type adminGift struct {
+	*model.Gift
+	ClaimList []giftClaim
+}
+
+func adminGifts(event *model.Event, guests map[uuid.UUID][]*model.Guest) []*adminGift {
+	res := make([]*adminGift, len(event.Gifts))
+	for i, g := range event.Gifts {
+		res[i] = &adminGift{Gift: g}
+		for inviteID, n := range g.Claims {
+			names := make([]string, 0, len(guests[inviteID]))
+			for _, guest := range guests[inviteID] {
+				names = append(names, strings.TrimSpace(guest.Firstname+" "+guest.Lastname))
+			}
+			res[i].ClaimList = append(res[i].ClaimList, giftClaim{
+				InvitationID: inviteID,
+				Guests:       strings.Join(names, ", "),
+				Quantity:     n,
+			})
+		}
+		sort.Slice(res[i].ClaimList, func(a, b int) bool {
+			return res[i].ClaimList[a].Guests < res[i].ClaimList[b].Guests
+		})
+	}
+	return res
+}
+
+// renderRegistry renders the gift registry as shown to the guests of the given
+// invitation.
+func (p *GuestHandler) renderRegistry(ctx context.Context, c *gin.Context, event *model.Event, inviteID uuid.UUID) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "GuestHandler.renderRegistry")
+	defer span.End()
+
+	lang, err := guestLanguage(ctx, c, p.tStore)
+	if err != nil {
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+	}
+	translation, err := translationWithFallback(ctx, p.tStore, lang)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unknown target language")
+		p.logger.ErrorContext(ctx, "unknown target language", "error", err)
+		c.String(http.StatusBadRequest, "unknown target language")
+		return
+	}
+
+	var deadline time.Time
+	if v, ok := c.Get(DeadlineKey); ok {
+		deadline, _ = v.(time.Time)
+	}
+
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"REGISTRY\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "registry.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse registry template")
+		p.logger.ErrorContext(ctx, "unable to parse registry template", "error", err)
+		return
+	}
+
+	if err := t.Execute(c.Writer, gin.H{
+		"id":          inviteID.String(),
+		"translation": translation,
+		"gifts":       guestGifts(event, inviteID),
+		"readOnly":    !deadline.IsZero() && deadline.Before(time.Now()),
+	}); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute registry template")
+		p.logger.ErrorContext(ctx, "unable to execute registry template", "error", err)
+	}
+}
+
+// ClaimGift claims one item of a gift for the requesting invitation.
+func (p *GuestHandler) ClaimGift(c *gin.Context) {
+	p.updateClaim(c, "GuestHandler.ClaimGift", func(gift *model.Gift, inviteID uuid.UUID) error {
+		return gift.Claim(inviteID)
+	})
+}
+
+// ReleaseGift releases one item of a gift claimed by the requesting
+// invitation. Like all changes, this is only possible before the deadline.
+func (p *GuestHandler) ReleaseGift(c *gin.Context) {
+	p.updateClaim(c, "GuestHandler.ReleaseGift", func(gift *model.Gift, inviteID uuid.UUID) error {
+		gift.Release(inviteID)
+		return nil
+	})
+}
+
+func (p *GuestHandler) updateClaim(c *gin.Context, spanName string, update func(*model.Gift, uuid.UUID) error) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, spanName)
+	defer span.End()
+
+	inviteID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid invitation ID")
+		return
+	}
+	giftID, err := uuid.Parse(c.Param("giftid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid gift ID")
+		return
+	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonProcess)
+		return
+	}
+
+	gift := event.Gift(giftID)
+	if gift == nil {
+		c.String(http.StatusNotFound, "gift not found")
+		return
+	}
+
+	if err := update(gift, inviteID); err != nil {
+		span.RecordError(err)
+		if errors.Is(err, model.ErrGiftUnavailable) {
+			NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonGiftUnavailable)
+			return
+		}
+		NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonProcess)
+		return
+	}
+
+	if err := p.eStore.UpdateEvent(ctx, event); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		NewErrorHandler(p.tStore).Handle(c, model.ErrorReasonProcess)
+		return
+	}
+
+	p.renderRegistry(ctx, c, event, inviteID)
+}
+
+func (p *GuestHandler) CreateGift(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.CreateGift")
+	defer span.End()
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	gift := &model.Gift{ID: uuid.New(), Quantity: 1}
+	e.Gifts = append(e.Gifts, gift)
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_EVENT_GIFT\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.event.registry.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse gift template")
+		p.logger.ErrorContext(ctx, "unable to parse gift template", "error", err)
+		return
+	}
+
+	if err := t.Execute(c.Writer, &adminGift{Gift: gift}); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute gift template")
+		p.logger.ErrorContext(ctx, "unable to execute gift template", "error", err)
+	}
+}
+
+func (p *GuestHandler) UpdateGifts(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UpdateGifts")
+	defer span.End()
+
+	if err := c.Request.ParseForm(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		c.String(http.StatusBadRequest, "could not parse form")
+		return
+	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	raw := p.parseForm(c.Request.PostForm)
+	for _, g := range e.Gifts {
+		data, ok := raw[g.ID.String()]
+		if !ok {
+			continue
+		}
+		if err := form.Unmarshal(data, g); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not parse gift")
+			p.logger.ErrorContext(ctx, "could not parse gift", "error", err)
+			c.String(http.StatusBadRequest, "could not parse gift")
+			return
+		}
+		g.Title = strings.TrimSpace(g.Title)
+		g.Link = strings.TrimSpace(g.Link)
+		if g.Quantity < g.Claimed() {
+			p.adminError(c, fmt.Sprintf("%d items of %q are already claimed.", g.Claimed(), g.Title))
+			return
+		}
+	}
+
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (p *GuestHandler) DeleteGift(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.DeleteGift")
+	defer span.End()
+
+	giftID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid gift ID")
+		return
+	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	for i := 0; i < len(e.Gifts); i++ {
+		if e.Gifts[i].ID == giftID {
+			e.Gifts = append(e.Gifts[:i], e.Gifts[i+1:]...)
+			break
+		}
+	}
+
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/locale"
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/parser/form"
+)
+
+// guestSubEvent is a sub-event as shown to guests in their language.
+type guestSubEvent struct {
+	ID       string
+	Label    string
+	Time     string
+	Location model.Location
+}
+
+func guestSubEvents(subEvents []*model.SubEvent, lang string, loc *locale.Locale, tz *time.Location) []guestSubEvent {
+	res := make([]guestSubEvent, len(subEvents))
+	for i, s := range subEvents {
+		res[i] = guestSubEvent{
+			ID:       s.ID.String(),
+			Label:    s.Label(lang),
+			Time:     loc.Format(s.Date.In(tz), loc.DateTimeLayout()),
+			Location: s.Location,
+		}
+	}
+	return res
+}
+
+// adminSubEvent is a sub-event together with the languages it can be labeled
+// in and the answers of the invited guests.
+type adminSubEvent struct {
+	*model.SubEvent
+	// TimeZone is the time zone of the event, which the date is shown in.
+	TimeZone  *time.Location
+	Languages []string
+	Accepted  int
+	Rejected  int
+	Pending   int
+}
+
+// LocalDate returns the date of the sub-event in the time zone of the event.
+func (s *adminSubEvent) LocalDate() time.Time {
+	return s.Date.In(s.TimeZone)
+}
+
+// InvitationLines returns the IDs of the invitations the sub-event is
+// restricted to, one per line.
+func (s *adminSubEvent) InvitationLines() string {
+	ids := make([]string, len(s.Invitations))
+	for i, id := range s.Invitations {
+		ids[i] = id.String()
+	}
+	return strings.Join(ids, "\n")
+}
+
+func adminSubEvents(event *model.Event, langs []string) []*adminSubEvent {
+	langs = append([]string(nil), langs...)
+	sort.Strings(langs)
+	res := make([]*adminSubEvent, len(event.SubEvents))
+	for i, s := range event.SubEvents {
+		res[i] = &adminSubEvent{SubEvent: s, TimeZone: event.TimeZone(), Languages: langs}
+	}
+	return res
+}
+
+// count adds the answer of a guest of the given invitation to the counts of
+// the sub-event.
+func (s *adminSubEvent) count(inviteID uuid.UUID, g *model.Guest) {
+	if !s.Invites(inviteID) {
+		return
+	}
+	switch g.SubEventStatus(s.ID.String()) {
+	case model.InvitationStatusAccepted:
+		s.Accepted++
+	case model.InvitationStatusRejected:
+		s.Rejected++
+	default:
+		s.Pending++
+	}
+}
+
+// keepSubEvents drops answers for sub-events the guest is not invited to and
+// answers that are neither an acceptance nor a rejection.
+func keepSubEvents(answers map[string]model.InvitationStatus, subEvents []*model.SubEvent) map[string]model.InvitationStatus {
+	var res map[string]model.InvitationStatus
+	for _, s := range subEvents {
+		status := answers[s.ID.String()]
+		if status != model.InvitationStatusAccepted && status != model.InvitationStatusRejected {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]model.InvitationStatus)
+		}
+		res[s.ID.String()] = status
+	}
+	return res
+}
+
+// checkSubEvents makes sure the sub-events the guests accepted still have
+// seats left. Guests that already had a seat keep it.
+//
+// The caller must hold capacityMu.
+func (p *GuestHandler) checkSubEvents(ctx context.Context, event *model.Event, changes []statusChange) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "GuestHandler.checkSubEvents")
+	defer span.End()
+
+	if len(event.SubEvents) == 0 {
+		return nil
+	}
+
+	changed := make(map[uuid.UUID]bool, len(changes))
+	for _, ch := range changes {
+		changed[ch.guest.ID] = true
+	}
+
+	guests, err := p.gStore.ListGuests(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list guests")
+		return err
+	}
+
+	for _, s := range event.SubEvents {
+		id := s.ID.String()
+		var accepted int
+		for _, g := range guests {
+			if !changed[g.ID] && g.SubEventStatus(id) == model.InvitationStatusAccepted {
+				accepted++
+			}
+		}
+		for _, ch := range changes {
+			if ch.previousSubEvents[id] == model.InvitationStatusAccepted && ch.guest.SubEventStatus(id) == model.InvitationStatusAccepted {
+				accepted++
+			}
+		}
+		for _, ch := range changes {
+			if ch.previousSubEvents[id] == model.InvitationStatusAccepted || ch.guest.SubEventStatus(id) != model.InvitationStatusAccepted {
+				continue
+			}
+			if !s.HasSeats(accepted) {
+				return fmt.Errorf("%w: %s", model.ErrSubEventFull, id)
+			}
+			accepted++
+		}
+	}
+	return nil
+}
+
+func (p *GuestHandler) CreateSubEvent(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.CreateSubEvent")
+	defer span.End()
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	langs, err := p.tStore.ListLanguages(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list languages")
+		p.logger.ErrorContext(ctx, "could not list languages", "error", err)
+		c.String(http.StatusInternalServerError, "could not list languages")
+		return
+	}
+
+	subEvent := &model.SubEvent{ID: uuid.New(), Date: e.Date}
+	e.SubEvents = append(e.SubEvents, subEvent)
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_EVENT_SUB_EVENT\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.event.schedule.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse sub-event template")
+		p.logger.ErrorContext(ctx, "unable to parse sub-event template", "error", err)
+		return
+	}
+
+	if err := t.Execute(c.Writer, adminSubEvents(&model.Event{Timezone: e.Timezone, SubEvents: []*model.SubEvent{subEvent}}, langs)[0]); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute sub-event template")
+		p.logger.ErrorContext(ctx, "unable to execute sub-event template", "error", err)
+		return
+	}
+}
+
+func (p *GuestHandler) UpdateSubEvents(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UpdateSubEvents")
+	defer span.End()
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		c.String(http.StatusBadRequest, "could not parse form")
+		return
+	}
+
+	raw := p.parseForm(c.Request.PostForm)
+	for _, s := range e.SubEvents {
+		data, ok := raw[s.ID.String()]
+		if !ok {
+			continue
+		}
+		if err := form.Unmarshal(data, s); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not parse sub-event")
+			p.logger.ErrorContext(ctx, "could not parse sub-event", "error", err)
+			c.String(http.StatusBadRequest, "could not parse sub-event")
+			return
+		}
+
+		if date, ok := data["date"]; ok && len(date) == 1 {
+			ts, err := parseDate(date[0], e.TimeZone())
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "could not parse sub-event date")
+				p.logger.ErrorContext(ctx, "could not parse sub-event date", "error", err)
+				c.String(http.StatusBadRequest, "could not parse sub-event date")
+				return
+			}
+			s.Date = ts
+		}
+
+		if invitations, ok := data["invitations"]; ok && len(invitations) == 1 {
+			s.Invitations = nil
+			for _, line := range splitLines(invitations[0]) {
+				line = strings.TrimSpace(line)
+				if line == "" {
+					continue
+				}
+				id, err := uuid.Parse(line)
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, "invalid invitation ID")
+					p.logger.ErrorContext(ctx, "invalid invitation ID", "error", err)
+					c.String(http.StatusBadRequest, "invalid invitation ID %q", line)
+					return
+				}
+				s.Invitations = append(s.Invitations, id)
+			}
+		}
+	}
+
+	sort.SliceStable(e.SubEvents, func(i, j int) bool {
+		return e.SubEvents[i].Date.Before(e.SubEvents[j].Date)
+	})
+
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (p *GuestHandler) DeleteSubEvent(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.DeleteSubEvent")
+	defer span.End()
+
+	subEventID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid sub-event ID")
+		return
+	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	for i := 0; i < len(e.SubEvents); i++ {
+		if e.SubEvents[i].ID == subEventID {
+			e.SubEvents = append(e.SubEvents[:i], e.SubEvents[i+1:]...)
+			break
+		}
+	}
+
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
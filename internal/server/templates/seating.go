@@ -0,0 +1,655 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/parser/form"
+)
+
+var tableShapeNames = map[model.TableShape]string{
+	model.TableShapeUnknown:     "unknown",
+	model.TableShapeRound:       "round",
+	model.TableShapeRectangular: "rectangular",
+	model.TableShapeSquare:      "square",
+}
+
+// dietCounts counts the diets of accepted guests.
+type dietCounts struct {
+	Unknown     int
+	Vegan       int
+	Vegetarian  int
+	Omnivore    int
+	DietaryTags map[string]int
+	Allergens   map[string]int
+}
+
+func newDietCounts() dietCounts {
+	return dietCounts{
+		DietaryTags: make(map[string]int),
+		Allergens:   make(map[string]int),
+	}
+}
+
+func (d *dietCounts) count(g *model.Guest) {
+	if g.InvitationStatus != model.InvitationStatusAccepted {
+		return
+	}
+	switch g.DietaryCategory {
+	case model.DietaryCategoryVegan:
+		d.Vegan++
+	case model.DietaryCategoryVegetarian:
+		d.Vegetarian++
+	case model.DietaryCatagoryOmnivore:
+		d.Omnivore++
+	default:
+		d.Unknown++
+	}
+	for _, tag := range g.DietaryTags {
+		d.DietaryTags[tag]++
+	}
+	for _, allergen := range g.Allergens {
+		d.Allergens[allergen]++
+	}
+}
+
+// adminTable is a table of the seating plan together with its guests and
+// their diets.
+type adminTable struct {
+	*model.Table
+	Guests []*model.Guest
+	Diet   dietCounts
+	// Unseated are the guests that can be seated at the table.
+	Unseated []*model.Guest
+}
+
+// ShapeName returns the name of the shape of the table.
+func (t *adminTable) ShapeName() string {
+	return exportName(tableShapeNames, t.Shape)
+}
+
+// seatingPlan is the seating plan as shown in the admin area.
+type seatingPlan struct {
+	Tables []*adminTable
+	// Unseated are the accepted guests that are not assigned to a table yet.
+	Unseated    []*model.Guest
+	DietaryTags []string
+	Allergens   []string
+	// invitations maps guests to their invitation.
+	invitations map[uuid.UUID]uuid.UUID
+}
+
+// seatingPlan loads all tables together with their guests.
+func (p *GuestHandler) seatingPlan(ctx context.Context) (*seatingPlan, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "GuestHandler.seatingPlan")
+	defer span.End()
+
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	tables, err := p.sStore.ListTables(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	invs, err := p.iStore.ListInvitations(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	guests, err := p.gStore.ListGuests(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*model.Guest, len(guests))
+	for _, g := range guests {
+		byID[g.ID] = g
+	}
+
+	plan := &seatingPlan{
+		DietaryTags: event.DietaryTagOptions(),
+		Allergens:   event.AllergenOptions(),
+		invitations: make(map[uuid.UUID]uuid.UUID),
+	}
+	for _, inv := range invs {
+		for _, gID := range inv.GuestIDs {
+			plan.invitations[gID] = inv.ID
+		}
+	}
+
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].Name != tables[j].Name {
+			return tables[i].Name < tables[j].Name
+		}
+		return tables[i].ID.String() < tables[j].ID.String()
+	})
+
+	seated := make(map[uuid.UUID]bool)
+	for _, table := range tables {
+		t := &adminTable{Table: table, Diet: newDietCounts()}
+		for _, gID := range table.GuestIDs {
+			g, ok := byID[gID]
+			if !ok {
+				p.logger.WarnContext(ctx, "seated guest does not exist", "id", gID.String(), "table", table.ID.String())
+				continue
+			}
+			seated[gID] = true
+			t.Guests = append(t.Guests, g)
+			t.Diet.count(g)
+		}
+		plan.Tables = append(plan.Tables, t)
+	}
+
+	for _, g := range guests {
+		if g.InvitationStatus == model.InvitationStatusAccepted && !seated[g.ID] {
+			plan.Unseated = append(plan.Unseated, g)
+		}
+	}
+	sort.Slice(plan.Unseated, func(i, j int) bool {
+		a, b := plan.Unseated[i], plan.Unseated[j]
+		if a.Lastname != b.Lastname {
+			return a.Lastname < b.Lastname
+		}
+		return a.Firstname < b.Firstname
+	})
+	for _, t := range plan.Tables {
+		t.Unseated = plan.Unseated
+	}
+	return plan, nil
+}
+
+// party returns the guests that are seated together with the given guest by
+// default, which are the accepted guests of the same invitation.
+func (p *GuestHandler) party(ctx context.Context, plan *seatingPlan, guestID uuid.UUID) ([]uuid.UUID, error) {
+	res := []uuid.UUID{guestID}
+	inviteID, ok := plan.invitations[guestID]
+	if !ok {
+		return res, nil
+	}
+	invite, err := p.iStore.GetInvitationByID(ctx, inviteID)
+	if err != nil {
+		return nil, err
+	}
+	for _, gID := range invite.GuestIDs {
+		if gID == guestID {
+			continue
+		}
+		g, err := p.gStore.GetGuestByID(ctx, gID)
+		if err != nil {
+			p.logger.WarnContext(ctx, "could not read guest", "error", err, "id", gID.String())
+			continue
+		}
+		if g.InvitationStatus == model.InvitationStatusAccepted {
+			res = append(res, gID)
+		}
+	}
+	return res, nil
+}
+
+// renderSeating renders the seating section of the admin area.
+func (p *GuestHandler) renderSeating(ctx context.Context, c *gin.Context) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "GuestHandler.renderSeating")
+	defer span.End()
+
+	plan, err := p.seatingPlan(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not load seating plan")
+		p.logger.ErrorContext(ctx, "could not load seating plan", "error", err)
+		c.String(http.StatusInternalServerError, "could not load seating plan")
+		return
+	}
+
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_SEATING\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.seating.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse seating template")
+		p.logger.ErrorContext(ctx, "unable to parse seating template", "error", err)
+		return
+	}
+
+	if err := t.Execute(c.Writer, plan); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute seating template")
+		p.logger.ErrorContext(ctx, "unable to execute seating template", "error", err)
+	}
+}
+
+// adminError shows an error toast in the admin area, regardless of the target
+// of the request.
+func (p *GuestHandler) adminError(c *gin.Context, message string) {
+	ctx := c.Request.Context()
+
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"TOAST_ERROR\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "toast.error.html")
+	if err != nil {
+		p.logger.ErrorContext(ctx, "unable to parse toast.error template", "error", err)
+		return
+	}
+
+	c.Header("HX-Retarget", "#toast-container")
+	c.Header("HX-Reswap", "beforeend")
+	if err := t.Execute(c.Writer, gin.H{"Title": "Error", "Message": message}); err != nil {
+		p.logger.ErrorContext(ctx, "unable to execute toast.error template", "error", err)
+	}
+}
+
+func (p *GuestHandler) CreateTable(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.CreateTable")
+	defer span.End()
+
+	p.seatingMu.Lock()
+	defer p.seatingMu.Unlock()
+
+	tables, err := p.sStore.ListTables(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list tables")
+		p.logger.ErrorContext(ctx, "could not list tables", "error", err)
+		c.String(http.StatusInternalServerError, "could not list tables")
+		return
+	}
+
+	table := &model.Table{
+		Name:  fmt.Sprintf("Table %d", len(tables)+1),
+		Shape: model.TableShapeRound,
+	}
+	if _, err := p.sStore.CreateTable(ctx, table); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not create table")
+		p.logger.ErrorContext(ctx, "could not create table", "error", err)
+		c.String(http.StatusInternalServerError, "could not create table")
+		return
+	}
+
+	p.renderSeating(ctx, c)
+}
+
+func (p *GuestHandler) UpdateTables(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UpdateTables")
+	defer span.End()
+
+	if err := c.Request.ParseForm(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		c.String(http.StatusBadRequest, "could not parse form")
+		return
+	}
+
+	p.seatingMu.Lock()
+	defer p.seatingMu.Unlock()
+
+	tables, err := p.sStore.ListTables(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list tables")
+		p.logger.ErrorContext(ctx, "could not list tables", "error", err)
+		c.String(http.StatusInternalServerError, "could not list tables")
+		return
+	}
+
+	raw := p.parseForm(c.Request.PostForm)
+	var changed []*model.Table
+	for _, table := range tables {
+		data, ok := raw[table.ID.String()]
+		if !ok {
+			continue
+		}
+		if err := form.Unmarshal(data, table); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not parse table")
+			p.logger.ErrorContext(ctx, "could not parse table", "error", err)
+			c.String(http.StatusBadRequest, "could not parse table")
+			return
+		}
+		table.Name = strings.TrimSpace(table.Name)
+		if err := table.Validate(); err != nil {
+			span.RecordError(err)
+			p.adminError(c, fmt.Sprintf("%s has %d guests, which exceeds its capacity of %d.", table.Name, len(table.GuestIDs), table.Capacity))
+			return
+		}
+		changed = append(changed, table)
+	}
+
+	for _, table := range changed {
+		if err := p.sStore.UpdateTable(ctx, table); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not update table")
+			p.logger.ErrorContext(ctx, "could not update table", "error", err)
+			c.String(http.StatusInternalServerError, "could not update table")
+			return
+		}
+	}
+
+	p.renderSeating(ctx, c)
+}
+
+func (p *GuestHandler) DeleteTable(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.DeleteTable")
+	defer span.End()
+
+	tableID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid table ID")
+		return
+	}
+
+	p.seatingMu.Lock()
+	defer p.seatingMu.Unlock()
+
+	if err := p.sStore.DeleteTable(ctx, tableID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not delete table")
+		p.logger.ErrorContext(ctx, "could not delete table", "error", err)
+		c.String(http.StatusInternalServerError, "could not delete table")
+		return
+	}
+
+	p.renderSeating(ctx, c)
+}
+
+// SeatGuest assigns a guest to a table. Unless they are explicitly split up,
+// the accepted guests of the same invitation are seated along with the guest.
+// Guests that are seated at another table are moved.
+func (p *GuestHandler) SeatGuest(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.SeatGuest")
+	defer span.End()
+
+	tableID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid table ID")
+		return
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		c.String(http.StatusBadRequest, "could not parse form")
+		return
+	}
+
+	var seat struct {
+		Guest    string `form:"seat_guest"`
+		Separate bool   `form:"seat_separate"`
+	}
+	if err := form.Unmarshal(p.parseForm(c.Request.PostForm)[tableID.String()], &seat); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse seat")
+		p.logger.ErrorContext(ctx, "could not parse seat", "error", err)
+		c.String(http.StatusBadRequest, "could not parse seat")
+		return
+	}
+	guestID, err := uuid.Parse(seat.Guest)
+	if err != nil {
+		span.RecordError(err)
+		p.adminError(c, "Please select a guest.")
+		return
+	}
+
+	p.seatingMu.Lock()
+	defer p.seatingMu.Unlock()
+
+	plan, err := p.seatingPlan(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not load seating plan")
+		p.logger.ErrorContext(ctx, "could not load seating plan", "error", err)
+		c.String(http.StatusInternalServerError, "could not load seating plan")
+		return
+	}
+
+	var target *model.Table
+	for _, t := range plan.Tables {
+		if t.ID == tableID {
+			target = t.Table
+		}
+	}
+	if target == nil {
+		c.String(http.StatusNotFound, "table not found")
+		return
+	}
+
+	guestIDs := []uuid.UUID{guestID}
+	if !seat.Separate {
+		guestIDs, err = p.party(ctx, plan, guestID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not find invitation")
+			p.logger.ErrorContext(ctx, "could not find invitation", "error", err)
+			c.String(http.StatusInternalServerError, "could not find invitation")
+			return
+		}
+	}
+
+	if err := target.Seat(guestIDs...); err != nil {
+		span.RecordError(err)
+		if errors.Is(err, model.ErrTableFull) {
+			p.adminError(c, fmt.Sprintf("%s has not enough free seats for %d guests.", target.Name, len(guestIDs)))
+			return
+		}
+		c.String(http.StatusInternalServerError, "could not seat guest")
+		return
+	}
+
+	for _, t := range plan.Tables {
+		if t.ID == tableID || !t.Unseat(guestIDs...) {
+			continue
+		}
+		if err := p.sStore.UpdateTable(ctx, t.Table); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not update table")
+			p.logger.ErrorContext(ctx, "could not update table", "error", err)
+			c.String(http.StatusInternalServerError, "could not update table")
+			return
+		}
+	}
+	if err := p.sStore.UpdateTable(ctx, target); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update table")
+		p.logger.ErrorContext(ctx, "could not update table", "error", err)
+		c.String(http.StatusInternalServerError, "could not update table")
+		return
+	}
+
+	p.renderSeating(ctx, c)
+}
+
+func (p *GuestHandler) UnseatGuest(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UnseatGuest")
+	defer span.End()
+
+	tableID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid table ID")
+		return
+	}
+	guestID, err := uuid.Parse(c.Param("guestid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, "invalid guest ID")
+		return
+	}
+
+	p.seatingMu.Lock()
+	defer p.seatingMu.Unlock()
+
+	table, err := p.sStore.GetTableByID(ctx, tableID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "table not found")
+		p.logger.WarnContext(ctx, "table not found", "error", err)
+		c.String(http.StatusNotFound, "table not found")
+		return
+	}
+
+	if table.Unseat(guestID) {
+		if err := p.sStore.UpdateTable(ctx, table); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not update table")
+			p.logger.ErrorContext(ctx, "could not update table", "error", err)
+			c.String(http.StatusInternalServerError, "could not update table")
+			return
+		}
+	}
+
+	p.renderSeating(ctx, c)
+}
+
+// RenderSeatingPrint renders a printable page with the guest list of every
+// table and a place card for every seated guest.
+func (p *GuestHandler) RenderSeatingPrint(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.RenderSeatingPrint")
+	defer span.End()
+
+	plan, err := p.seatingPlan(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not load seating plan")
+		p.logger.ErrorContext(ctx, "could not load seating plan", "error", err)
+		c.String(http.StatusInternalServerError, "could not load seating plan")
+		return
+	}
+
+	t, err := template.New("seating.print.html").Funcs(pageFuncs(p.static)).ParseFS(templates, "seating.print.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse seating print template")
+		p.logger.ErrorContext(ctx, "unable to parse seating print template", "error", err)
+		return
+	}
+	if err := t.ExecuteTemplate(c.Writer, "SEATING_PRINT", plan); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute seating print template")
+		p.logger.ErrorContext(ctx, "unable to execute seating print template", "error", err)
+	}
+}
+
+// ExportSeating writes the guests of every table as CSV.
+func (p *GuestHandler) ExportSeating(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ExportSeating")
+	defer span.End()
+
+	plan, err := p.seatingPlan(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not load seating plan")
+		p.logger.ErrorContext(ctx, "could not load seating plan", "error", err)
+		c.String(http.StatusInternalServerError, "could not load seating plan")
+		return
+	}
+
+	records := [][]string{{
+		"table", "capacity", "shape", "invitation", "guest", "firstname", "lastname",
+		"status", "diet", "dietary tags", "allergens", "dietary note",
+	}}
+	for _, t := range plan.Tables {
+		for _, g := range t.Guests {
+			records = append(records, []string{
+				t.Name,
+				strconv.Itoa(t.Capacity),
+				t.ShapeName(),
+				plan.invitations[g.ID].String(),
+				g.ID.String(),
+				g.Firstname,
+				g.Lastname,
+				exportName(invitationStatusNames, g.InvitationStatus),
+				exportName(dietaryCategoryNames, g.DietaryCategory),
+				strings.Join(g.DietaryTags, "; "),
+				strings.Join(g.Allergens, "; "),
+				g.DietaryNote,
+			})
+		}
+	}
+	p.writeCSV(ctx, c, "seating.csv", records)
+}
+
+// ExportSeatingDiet writes the diets of the accepted guests of every table as
+// CSV, so the caterer knows what to serve at which table.
+func (p *GuestHandler) ExportSeatingDiet(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ExportSeatingDiet")
+	defer span.End()
+
+	plan, err := p.seatingPlan(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not load seating plan")
+		p.logger.ErrorContext(ctx, "could not load seating plan", "error", err)
+		c.String(http.StatusInternalServerError, "could not load seating plan")
+		return
+	}
+
+	header := []string{"table", "guests", "unknown", "vegan", "vegetarian", "omnivore"}
+	for _, tag := range plan.DietaryTags {
+		header = append(header, "tag "+tag)
+	}
+	for _, allergen := range plan.Allergens {
+		header = append(header, "allergic to "+allergen)
+	}
+	records := [][]string{header}
+	for _, t := range plan.Tables {
+		d := t.Diet
+		record := []string{
+			t.Name,
+			strconv.Itoa(d.Unknown + d.Vegan + d.Vegetarian + d.Omnivore),
+			strconv.Itoa(d.Unknown),
+			strconv.Itoa(d.Vegan),
+			strconv.Itoa(d.Vegetarian),
+			strconv.Itoa(d.Omnivore),
+		}
+		for _, tag := range plan.DietaryTags {
+			record = append(record, strconv.Itoa(d.DietaryTags[tag]))
+		}
+		for _, allergen := range plan.Allergens {
+			record = append(record, strconv.Itoa(d.Allergens[allergen]))
+		}
+		records = append(records, record)
+	}
+	p.writeCSV(ctx, c, "seating-diet.csv", records)
+}
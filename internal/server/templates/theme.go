@@ -0,0 +1,369 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/media"
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/parser/form"
+)
+
+// defaultBanner is the static file shown as banner without a theme banner.
+const defaultBanner = "lets-party.png"
+
+// themeScope is the root of the styles of a theme on the invitations.
+const themeScope = ":root"
+
+// previewScope is the root of the styles of the theme preview in the admin
+// area, so the theme does not change the admin area itself.
+const previewScope = ".theme-preview"
+
+// themeCSS renders the styles of a theme for the elements within scope. The
+// templates mark the themed elements by the classes theme-nav, theme-banner,
+// theme-button and theme-link.
+func themeCSS(theme *model.Theme, scope string) string {
+	if theme.IsZero() {
+		return ""
+	}
+	var b strings.Builder
+	if font := theme.FontFamily(); font != "" {
+		fmt.Fprintf(&b, "%s { font-family: %s; }\n", scope, font)
+	}
+	if theme.PrimaryColor != "" {
+		fmt.Fprintf(&b, "%[1]s .theme-nav, %[1]s .theme-banner { background-color: %[2]s; background-image: none; }\n", scope, theme.PrimaryColor)
+	}
+	if theme.AccentColor != "" {
+		fmt.Fprintf(&b, "%s .theme-button { background-color: %s; }\n", scope, theme.AccentColor)
+		fmt.Fprintf(&b, "%s .theme-button:hover { filter: brightness(1.1); }\n", scope)
+		fmt.Fprintf(&b, "%s .theme-link { color: %s; }\n", scope, theme.AccentColor)
+	}
+	if css := strings.TrimSpace(theme.CSS); css != "" {
+		// NOTE: the custom CSS is nested into the scope, unless it applies to
+		// the whole page. model.Theme.Check ensures it can not break out.
+		if scope == themeScope {
+			b.WriteString(css + "\n")
+		} else {
+			fmt.Fprintf(&b, "%s {\n%s\n}\n", scope, css)
+		}
+	}
+	return b.String()
+}
+
+// contentHash returns a short hash of s, used in URLs so browsers can cache
+// the themed files until they change.
+func contentHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// themeURLs returns the URL of the styles and the banner of a theme for the
+// invitations, or "" if the theme does not set them.
+func themeURLs(theme *model.Theme) (css, banner string) {
+	if s := themeCSS(theme, themeScope); s != "" {
+		css = "/theme.css?v=" + contentHash(s)
+	}
+	switch {
+	case theme.IsZero():
+	case theme.BannerImage != nil:
+		banner = imageSrc(theme.BannerImage)
+	case theme.Banner != "":
+		banner = "/theme/banner?v=" + contentHash(theme.Banner)
+	}
+	return css, banner
+}
+
+// setThemeCache lets browsers cache a themed file forever if it was requested
+// by the hash of its current content.
+func setThemeCache(c *gin.Context, content string) {
+	if c.Query("v") == contentHash(content) {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+}
+
+// themeFromForm reads the theme settings of the admin form. The banner is
+// not part of the form values.
+func themeFromForm(values url.Values) (*model.Theme, error) {
+	theme := &model.Theme{}
+	if err := form.Unmarshal(values, theme); err != nil {
+		return nil, err
+	}
+	theme.PrimaryColor = strings.ToLower(strings.TrimSpace(theme.PrimaryColor))
+	theme.AccentColor = strings.ToLower(strings.TrimSpace(theme.AccentColor))
+	theme.Font = strings.TrimSpace(theme.Font)
+	theme.CSS = strings.TrimSpace(strings.ReplaceAll(theme.CSS, "\r\n", "\n"))
+	return theme, theme.Check()
+}
+
+// decodeDataURL returns the content type and data of a base64 data URL.
+func decodeDataURL(src string) (string, []byte, error) {
+	rest, ok := strings.CutPrefix(src, "data:")
+	if !ok {
+		return "", nil, errors.New("no data URL")
+	}
+	contentType, data, ok := strings.Cut(rest, ";base64,")
+	if !ok {
+		return "", nil, errors.New("data URL is not base64 encoded")
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	return contentType, raw, err
+}
+
+// ThemeCSS serves the styles of the event theme for the invitations.
+func (p *GuestHandler) ThemeCSS(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ThemeCSS")
+	defer span.End()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	css := themeCSS(e.Theme, themeScope)
+	setThemeCache(c, css)
+	c.Data(http.StatusOK, "text/css; charset=utf-8", []byte(css))
+}
+
+// ThemeBanner serves the banner of the event theme that was stored as data
+// URL. Newer banners are served as media.
+func (p *GuestHandler) ThemeBanner(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ThemeBanner")
+	defer span.End()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+	if e.Theme.IsZero() || e.Theme.Banner == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	contentType, data, err := decodeDataURL(e.Theme.Banner)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not decode banner")
+		p.logger.ErrorContext(ctx, "could not decode banner", "error", err)
+		c.String(http.StatusInternalServerError, "could not decode banner")
+		return
+	}
+	setThemeCache(c, e.Theme.Banner)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// themePreview is the data of the theme preview in the admin area.
+type themePreview struct {
+	CSS          string
+	Banner       string
+	BannerSrcset string
+	Error        string
+}
+
+func (p *GuestHandler) newThemePreview(saved *model.Theme, values url.Values) *themePreview {
+	preview := &themePreview{}
+	// NOTE: the default banner is one of RequiredAssets, which are checked
+	// at startup.
+	preview.Banner, _ = p.static.Path(defaultBanner)
+	if _, banner := themeURLs(saved); banner != "" {
+		preview.Banner = banner
+	}
+	if !saved.IsZero() {
+		preview.BannerSrcset = imageSrcset(saved.BannerImage)
+	}
+	if _, err := themeFromForm(values); err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+	preview.CSS = "/admin/theme/preview.css?" + values.Encode()
+	return preview
+}
+
+// themeFormValues returns the settings of a theme as values of the admin form.
+func themeFormValues(theme *model.Theme) url.Values {
+	if theme == nil {
+		theme = &model.Theme{}
+	}
+	return url.Values{
+		"primary_color": {theme.PrimaryColor},
+		"accent_color":  {theme.AccentColor},
+		"font":          {theme.Font},
+		"css":           {theme.CSS},
+	}
+}
+
+// RenderThemePreview renders the preview of the theme settings entered in the
+// admin form, before they are saved.
+func (p *GuestHandler) RenderThemePreview(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.RenderThemePreview")
+	defer span.End()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	values := url.Values{}
+	for _, key := range []string{"primary_color", "accent_color", "font", "css"} {
+		values.Set(key, c.Query(key))
+	}
+
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_THEME_PREVIEW\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.theme.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse theme template")
+		p.logger.ErrorContext(ctx, "unable to parse theme template", "error", err)
+		return
+	}
+
+	if err := t.Execute(c.Writer, p.newThemePreview(e.Theme, values)); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute theme template")
+		p.logger.ErrorContext(ctx, "unable to execute theme template", "error", err)
+	}
+}
+
+// ThemePreviewCSS serves the styles of the theme settings given as query,
+// limited to the preview in the admin area.
+func (p *GuestHandler) ThemePreviewCSS(c *gin.Context) {
+	_, span := tracer.Start(c.Request.Context(), "GuestHandler.ThemePreviewCSS")
+	defer span.End()
+
+	theme, err := themeFromForm(c.Request.URL.Query())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/css; charset=utf-8", []byte(themeCSS(theme, previewScope)))
+}
+
+// UpdateTheme saves the theme settings of the admin form. An uploaded banner
+// is scaled like photos and replaces the previous one.
+func (p *GuestHandler) UpdateTheme(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UpdateTheme")
+	defer span.End()
+
+	limitImageUpload(c)
+	if err := c.Request.ParseMultipartForm(maxImageSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		p.adminError(c, fmt.Sprintf("The banner must not be larger than %d MB.", maxImageSize>>20))
+		return
+	}
+
+	theme, err := themeFromForm(c.Request.PostForm)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.adminError(c, err.Error())
+		return
+	}
+
+	data, err := readImage(c, "banner")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		p.adminError(c, fmt.Sprintf("Could not read the banner: %s.", err))
+		return
+	}
+	if data != nil {
+		theme.BannerImage, err = media.Store(ctx, p.bStore, data)
+		if errors.Is(err, media.ErrUnsupported) || errors.Is(err, media.ErrTooLarge) {
+			p.adminError(c, fmt.Sprintf("The banner can not be used: %s.", err))
+			return
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not store banner")
+			p.logger.ErrorContext(ctx, "could not store banner", "error", err)
+			c.String(http.StatusInternalServerError, "could not store banner")
+			return
+		}
+	}
+
+	p.eventMu.Lock()
+	defer p.eventMu.Unlock()
+
+	e, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		_ = media.Delete(ctx, p.bStore, theme.BannerImage)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	// NOTE: the previous banner is kept, unless it is removed or replaced.
+	var replaced *model.Image
+	if e.Theme != nil {
+		switch {
+		case data != nil || c.PostForm("remove_banner") != "":
+			replaced = e.Theme.BannerImage
+		default:
+			theme.BannerImage = e.Theme.BannerImage
+			theme.Banner = e.Theme.Banner
+		}
+	}
+
+	e.Theme = theme
+	if theme.IsZero() {
+		e.Theme = nil
+	}
+	if err := p.eStore.UpdateEvent(ctx, e); err != nil {
+		if data != nil {
+			_ = media.Delete(ctx, p.bStore, theme.BannerImage)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update event")
+		p.logger.ErrorContext(ctx, "could not update event", "error", err)
+		c.String(http.StatusInternalServerError, "could not update event")
+		return
+	}
+	if err := media.Delete(ctx, p.bStore, replaced); err != nil {
+		span.RecordError(err)
+		p.logger.ErrorContext(ctx, "could not delete previous banner", "error", err)
+	}
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,315 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/quixsi/core/internal/db"
+	"github.com/quixsi/core/internal/locale"
+	"github.com/quixsi/core/internal/model"
+)
+
+// translationWithFallback returns the translation of a language shown to
+// guests. Missing texts are taken from the languages of its fallback chain,
+// e.g. "de-AT", "de" and "en". It fails only if no language of the chain
+// exists.
+func translationWithFallback(ctx context.Context, tStore db.TranslationStore, lang string) (*model.Translation, error) {
+	var (
+		res      *model.Translation
+		firstErr error
+	)
+	for _, l := range model.FallbackChain(lang) {
+		t, err := tStore.ByLanguage(ctx, l)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if res == nil {
+			res = t
+			continue
+		}
+		res = res.WithFallback(t)
+	}
+	if res == nil {
+		return nil, firstErr
+	}
+	return res, nil
+}
+
+// textDirection returns the direction of the text of a translation, "ltr"
+// or "rtl". Without an explicit direction, it is the one of the locale.
+func textDirection(translation *model.Translation, lang string) string {
+	if translation.Direction != "" {
+		return translation.Direction
+	}
+	return locale.Direction(translation.Tag(lang))
+}
+
+// missingTranslations returns the keys of the texts missing in each language
+// compared to the reference language. Languages without missing texts are
+// left out.
+func missingTranslations(ctx context.Context, tStore db.TranslationStore, reference string) (map[string]map[string]bool, error) {
+	ref, err := tStore.ByLanguage(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	langs, err := tStore.ListLanguages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]map[string]bool)
+	for _, lang := range langs {
+		if lang == reference {
+			continue
+		}
+		t, err := tStore.ByLanguage(ctx, lang)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range t.Missing(ref) {
+			if res[lang] == nil {
+				res[lang] = make(map[string]bool)
+			}
+			res[lang][key] = true
+		}
+	}
+	return res, nil
+}
+
+// maxFlagSize is the maximum size of an uploaded flag image in bytes.
+const maxFlagSize = 256 << 10
+
+// flagTypes are the content types of the images accepted as flags. SVG is
+// left out, since it may contain scripts.
+var flagTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// flagURL returns the flag of a translation as URL usable in templates, or ""
+// if it is no uploaded image.
+func flagURL(src string) template.URL {
+	if !strings.HasPrefix(src, "data:image/") {
+		return ""
+	}
+	return template.URL(src)
+}
+
+// CloneLanguage creates a new language as a copy of an existing one. The key
+// of the new language is read from the htmx prompt or the form value "to".
+func (t *TranslationHandler) CloneLanguage(c *gin.Context) {
+	ctx, span := tracer.Start(c.Request.Context(), "TranslationHandler.CloneLanguage")
+	defer span.End()
+
+	from := c.Param("lang")
+	to := promptValue(c, "to")
+	if err := model.CheckLanguage(to); err != nil {
+		err := fmt.Errorf("%q: %w", to, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	translation, err := t.tStore.ByLanguage(ctx, from)
+	if err != nil {
+		err := fmt.Errorf("cannot find language %q: %w", from, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := t.tStore.CreateLanguage(ctx, to, translation); err != nil {
+		err := fmt.Errorf("create language %q: %w", to, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusNoContent)
+}
+
+// UploadFlag replaces the flag of a language by the uploaded image, which is
+// stored as data URL.
+func (t *TranslationHandler) UploadFlag(c *gin.Context) {
+	ctx, span := tracer.Start(c.Request.Context(), "TranslationHandler.UploadFlag")
+	defer span.End()
+
+	lang := c.Param("lang")
+	translation, err := t.tStore.ByLanguage(ctx, lang)
+	if err != nil {
+		err := fmt.Errorf("cannot find language %q: %w", lang, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// NOTE: the limit leaves room for the headers of the multipart form.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxFlagSize+(4<<10))
+	file, _, err := c.Request.FormFile("flag")
+	if err != nil {
+		err := fmt.Errorf("read flag: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxFlagSize+1))
+	if err != nil {
+		err := fmt.Errorf("read flag: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(data) > maxFlagSize {
+		err := fmt.Errorf("flag is larger than %d KB", maxFlagSize>>10)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(flagTypes, contentType) {
+		err := fmt.Errorf("flag of type %q is no PNG, JPEG, GIF or WebP image", contentType)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	translation.FlagImgSrc = "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	if err := t.tStore.UpdateLanguages(ctx, map[string]*model.Translation{lang: translation}); err != nil {
+		err := fmt.Errorf("update language %q: %w", lang, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusNoContent)
+}
+
+// promptValue returns the answer to an htmx prompt, or the form value with
+// the given name for requests without a prompt.
+func promptValue(c *gin.Context, name string) string {
+	if v := strings.TrimSpace(c.GetHeader("HX-Prompt")); v != "" {
+		return v
+	}
+	return strings.TrimSpace(c.PostForm(name))
+}
+
+// DeleteLanguage removes a language. The last language can not be deleted,
+// since the invitations could not be shown anymore.
+func (t *TranslationHandler) DeleteLanguage(c *gin.Context) {
+	ctx, span := tracer.Start(c.Request.Context(), "TranslationHandler.DeleteLanguage")
+	defer span.End()
+
+	lang := c.Param("lang")
+	langs, err := t.tStore.ListLanguages(ctx)
+	if err != nil {
+		err := fmt.Errorf("list languages: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(langs) == 1 && langs[0] == lang {
+		err := fmt.Errorf("cannot delete the last language %q", lang)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	t.inviteMu.Lock()
+	defer t.inviteMu.Unlock()
+
+	if err := t.tStore.DeleteLanguage(ctx, lang); err != nil {
+		err := fmt.Errorf("delete language %q: %w", lang, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := t.replaceDefaultLanguage(ctx, lang, ""); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.ErrorContext(ctx, "could not clear default language", "error", err)
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusNoContent)
+}
+
+// RenameLanguage changes the key of a language, e.g. from "de" to "de-AT".
+// The new key is read from the htmx prompt or the form value "to".
+func (t *TranslationHandler) RenameLanguage(c *gin.Context) {
+	ctx, span := tracer.Start(c.Request.Context(), "TranslationHandler.RenameLanguage")
+	defer span.End()
+
+	from := c.Param("lang")
+	to := promptValue(c, "to")
+	if err := model.CheckLanguage(to); err != nil {
+		err := fmt.Errorf("%q: %w", to, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	t.inviteMu.Lock()
+	defer t.inviteMu.Unlock()
+
+	if err := t.tStore.RenameLanguage(ctx, from, to); err != nil {
+		err := fmt.Errorf("rename language %q to %q: %w", from, to, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := t.replaceDefaultLanguage(ctx, from, to); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.logger.ErrorContext(ctx, "could not rename default language", "error", err)
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusNoContent)
+}
+
+// replaceDefaultLanguage changes the default language of the invitations
+// from one language to another, or clears it if to is "". The caller must
+// hold the invitation lock.
+func (t *TranslationHandler) replaceDefaultLanguage(ctx context.Context, from, to string) error {
+	invites, err := t.iStore.ListInvitations(ctx)
+	if err != nil {
+		return fmt.Errorf("list invitations: %w", err)
+	}
+	for _, invite := range invites {
+		if invite.DefaultLanguage != from {
+			continue
+		}
+		invite.DefaultLanguage = to
+		if err := t.iStore.UpdateInvitation(ctx, invite); err != nil {
+			return fmt.Errorf("update invitation %s: %w", invite.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/model"
+)
+
+// defaultTravelWindow is the time window arrivals and departures are grouped
+// by if the admin does not choose another one.
+const defaultTravelWindow = 2 * time.Hour
+
+// travelWindows are the time windows, in minutes, the admin can choose from.
+var travelWindows = []int{30, 60, 120, 240}
+
+// travelOptions are the hotels and airports guests can choose from.
+type travelOptions struct {
+	Hotels   []choiceOption
+	Airports []choiceOption
+}
+
+func newTravelOptions(event *model.Event) travelOptions {
+	var res travelOptions
+	for _, h := range event.Hotels {
+		res.Hotels = append(res.Hotels, choiceOption{Value: h.ID.String(), Label: h.Name})
+	}
+	for _, a := range event.Airports {
+		res.Airports = append(res.Airports, choiceOption{Value: a.ID.String(), Label: a.Name})
+	}
+	return res
+}
+
+// parseTravelTimes parses the arrival and departure of a guest, which are
+// entered in the time zone of the event.
+func parseTravelTimes(data url.Values, t *model.Travel, loc *time.Location) error {
+	var err error
+	parse := func(key string) (*time.Time, error) {
+		value := strings.TrimSpace(data.Get(key))
+		if value == "" {
+			return nil, nil
+		}
+		ts, err := time.ParseInLocation(dateInputLayout, value, loc)
+		if err != nil {
+			return nil, err
+		}
+		return &ts, nil
+	}
+
+	if _, ok := data["travel.arrival_at"]; ok {
+		if t.ArrivalAt, err = parse("travel.arrival_at"); err != nil {
+			return err
+		}
+	}
+	if _, ok := data["travel.departure_at"]; ok {
+		if t.DepartureAt, err = parse("travel.departure_at"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// travelGroup are the guests arriving at or departing from an airport within
+// a time window. From is zero for guests that did not state a time.
+type travelGroup struct {
+	Airport string
+	From    time.Time
+	To      time.Time
+	Guests  []*model.Guest
+}
+
+// hotelOccupancy compares the guests staying at a hotel with its room block.
+type hotelOccupancy struct {
+	Name   string
+	Guests int
+	// Parties is the number of invitations with guests staying at the hotel,
+	// which estimates the number of rooms needed.
+	Parties   int
+	RoomBlock int
+}
+
+// OverBooked reports whether more rooms are needed than blocked.
+func (h *hotelOccupancy) OverBooked() bool {
+	return h.RoomBlock > 0 && h.Parties > h.RoomBlock
+}
+
+// travelReport groups the arrivals and departures of the accepted guests for
+// shuttle planning and lists the hotel occupancy.
+type travelReport struct {
+	Window     int
+	Windows    []int
+	Arrivals   []*travelGroup
+	Departures []*travelGroup
+	Hotels     []*hotelOccupancy
+}
+
+func (p *GuestHandler) travelReport(ctx context.Context, window time.Duration) (*travelReport, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "GuestHandler.travelReport")
+	defer span.End()
+
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	invs, err := p.iStore.ListInvitations(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	loc := event.TimeZone()
+	report := &travelReport{Window: int(window.Minutes()), Windows: travelWindows}
+	hotels := make(map[string]*hotelOccupancy, len(event.Hotels))
+	for _, h := range event.Hotels {
+		occupancy := &hotelOccupancy{Name: h.Name, RoomBlock: h.RoomBlock}
+		hotels[h.ID.String()] = occupancy
+		report.Hotels = append(report.Hotels, occupancy)
+	}
+
+	arrivals := make(map[travelKey]*travelGroup)
+	departures := make(map[travelKey]*travelGroup)
+	for _, inv := range invs {
+		parties := make(map[string]bool)
+		for _, gID := range inv.GuestIDs {
+			g, err := p.gStore.GetGuestByID(ctx, gID)
+			if err != nil {
+				p.logger.WarnContext(ctx, "could not read guest", "error", err, "id", gID.String())
+				continue
+			}
+			if g.InvitationStatus != model.InvitationStatusAccepted {
+				continue
+			}
+			if h, ok := hotels[g.Travel.Hotel]; ok {
+				h.Guests++
+				if !parties[g.Travel.Hotel] {
+					parties[g.Travel.Hotel] = true
+					h.Parties++
+				}
+			}
+			if a := event.Airport(g.Travel.ArrivalAirport); a != nil {
+				addToTravelGroup(arrivals, a.Name, g.Travel.ArrivalAt, window, loc, g)
+			}
+			if a := event.Airport(g.Travel.DepartureAirport); a != nil {
+				addToTravelGroup(departures, a.Name, g.Travel.DepartureAt, window, loc, g)
+			}
+		}
+	}
+
+	report.Arrivals = sortTravelGroups(arrivals)
+	report.Departures = sortTravelGroups(departures)
+	return report, nil
+}
+
+type travelKey struct {
+	airport string
+	from    time.Time
+}
+
+func addToTravelGroup(groups map[travelKey]*travelGroup, airport string, at *time.Time, window time.Duration, loc *time.Location, g *model.Guest) {
+	key := travelKey{airport: airport}
+	if at != nil {
+		key.from = travelWindowStart(*at, window, loc)
+	}
+	group, ok := groups[key]
+	if !ok {
+		group = &travelGroup{Airport: airport, From: key.from}
+		if !key.from.IsZero() {
+			group.To = key.from.Add(window)
+		}
+		groups[key] = group
+	}
+	group.Guests = append(group.Guests, g)
+}
+
+// travelWindowStart returns the start of the time window containing t. The
+// windows are aligned to midnight in the given location.
+func travelWindowStart(t time.Time, window time.Duration, loc *time.Location) time.Time {
+	t = t.In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return midnight.Add(t.Sub(midnight).Truncate(window))
+}
+
+func sortTravelGroups(groups map[travelKey]*travelGroup) []*travelGroup {
+	res := make([]*travelGroup, 0, len(groups))
+	for _, g := range groups {
+		sort.Slice(g.Guests, func(i, j int) bool {
+			return g.Guests[i].Lastname+g.Guests[i].Firstname < g.Guests[j].Lastname+g.Guests[j].Firstname
+		})
+		res = append(res, g)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Airport != res[j].Airport {
+			return res[i].Airport < res[j].Airport
+		}
+		return res[i].From.Before(res[j].From)
+	})
+	return res
+}
+
+// travelWindow returns the time window requested by the admin.
+func travelWindow(c *gin.Context) time.Duration {
+	minutes, err := strconv.Atoi(c.Query("window"))
+	if err != nil || minutes <= 0 {
+		return defaultTravelWindow
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// RenderTravel renders the travel report of the admin area.
+func (p *GuestHandler) RenderTravel(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.RenderTravel")
+	defer span.End()
+
+	report, err := p.travelReport(ctx, travelWindow(c))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not create travel report")
+		p.logger.ErrorContext(ctx, "could not create travel report", "error", err)
+		c.String(http.StatusInternalServerError, "could not create travel report")
+		return
+	}
+
+	wrapperTemplate, _ := template.New("wrapper").Parse("{{ template \"ADMIN_TRAVEL\" .}}")
+	t, err := wrapperTemplate.ParseFS(templates, "admin.travel.html")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to parse travel template")
+		p.logger.ErrorContext(ctx, "unable to parse travel template", "error", err)
+		return
+	}
+
+	if err := t.Execute(c.Writer, report); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unable to execute travel template")
+		p.logger.ErrorContext(ctx, "unable to execute travel template", "error", err)
+	}
+}
+
+// ExportTravel writes the arrivals and departures grouped by airport and time
+// window as CSV.
+func (p *GuestHandler) ExportTravel(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.ExportTravel")
+	defer span.End()
+
+	report, err := p.travelReport(ctx, travelWindow(c))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not create travel report")
+		p.logger.ErrorContext(ctx, "could not create travel report", "error", err)
+		c.String(http.StatusInternalServerError, "could not create travel report")
+		return
+	}
+
+	records := [][]string{{"direction", "airport", "from", "to", "guest", "firstname", "lastname", "time", "hotel"}}
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+	add := func(direction string, groups []*travelGroup, at func(model.Travel) *time.Time) {
+		for _, group := range groups {
+			from, to := "", ""
+			if !group.From.IsZero() {
+				from, to = group.From.Format(time.RFC3339), group.To.Format(time.RFC3339)
+			}
+			for _, g := range group.Guests {
+				var ts, hotel string
+				if t := at(g.Travel); t != nil {
+					ts = t.In(event.TimeZone()).Format(time.RFC3339)
+				}
+				if h := event.Hotel(g.Travel.Hotel); h != nil {
+					hotel = h.Name
+				}
+				records = append(records, []string{
+					direction, group.Airport, from, to,
+					g.ID.String(), g.Firstname, g.Lastname, ts, hotel,
+				})
+			}
+		}
+	}
+	add("arrival", report.Arrivals, func(t model.Travel) *time.Time { return t.ArrivalAt })
+	add("departure", report.Departures, func(t model.Travel) *time.Time { return t.DepartureAt })
+
+	p.writeCSV(ctx, c, "travel.csv", records)
+}
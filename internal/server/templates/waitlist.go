@@ -0,0 +1,220 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/quixsi/core/internal/model"
+	"github.com/quixsi/core/internal/parser/form"
+)
+
+// statusChange holds a guest with its updated status together with the
+// status it had before, for the event as well as for its sub-events.
+type statusChange struct {
+	guest             *model.Guest
+	previous          model.InvitationStatus
+	previousSubEvents map[string]model.InvitationStatus
+}
+
+// applyCapacity puts guests on the waitlist that accepted while the event is
+// fully booked. Guests that already had a seat keep it and guests that are
+// already waiting keep their place in line. It reports whether one of the
+// guests ended up on the waitlist.
+//
+// The caller must hold capacityMu.
+func (p *GuestHandler) applyCapacity(ctx context.Context, event *model.Event, changes []statusChange) (bool, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "GuestHandler.applyCapacity")
+	defer span.End()
+
+	changed := make(map[uuid.UUID]bool, len(changes))
+	for _, ch := range changes {
+		changed[ch.guest.ID] = true
+	}
+
+	guests, err := p.gStore.ListGuests(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list guests")
+		return false, err
+	}
+
+	var accepted int
+	for _, g := range guests {
+		if !changed[g.ID] && g.InvitationStatus == model.InvitationStatusAccepted {
+			accepted++
+		}
+	}
+	for _, ch := range changes {
+		if ch.previous == model.InvitationStatusAccepted && ch.guest.InvitationStatus == model.InvitationStatusAccepted {
+			accepted++
+		}
+	}
+
+	var waitlisted bool
+	for _, ch := range changes {
+		g := ch.guest
+		switch {
+		case g.InvitationStatus == model.InvitationStatusAccepted && ch.previous == model.InvitationStatusAccepted:
+			continue
+		case g.InvitationStatus == model.InvitationStatusAccepted && ch.previous == model.InvitationStatusWaitlisted:
+			g.InvitationStatus = model.InvitationStatusWaitlisted
+		case g.InvitationStatus == model.InvitationStatusAccepted && event.HasSeats(accepted):
+			accepted++
+			continue
+		case g.InvitationStatus == model.InvitationStatusAccepted:
+			g.InvitationStatus = model.InvitationStatusWaitlisted
+		}
+
+		if g.InvitationStatus != model.InvitationStatusWaitlisted {
+			g.WaitlistedAt = nil
+			continue
+		}
+		if g.WaitlistedAt == nil {
+			now := time.Now()
+			g.WaitlistedAt = &now
+		}
+		waitlisted = true
+	}
+	return waitlisted, nil
+}
+
+// promoteWaitlist accepts waiting guests in the order they joined the
+// waitlist for as long as seats are available.
+//
+// The caller must hold capacityMu.
+func (p *GuestHandler) promoteWaitlist(ctx context.Context, event *model.Event) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "GuestHandler.promoteWaitlist")
+	defer span.End()
+
+	guests, err := p.gStore.ListGuests(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not list guests")
+		return err
+	}
+
+	var accepted int
+	var waiting []*model.Guest
+	for _, g := range guests {
+		switch g.InvitationStatus {
+		case model.InvitationStatusAccepted:
+			accepted++
+		case model.InvitationStatusWaitlisted:
+			waiting = append(waiting, g)
+		}
+	}
+
+	sort.SliceStable(waiting, func(i, j int) bool {
+		if waiting[i].WaitlistedAt == nil || waiting[j].WaitlistedAt == nil {
+			return waiting[j].WaitlistedAt == nil && waiting[i].WaitlistedAt != nil
+		}
+		return waiting[i].WaitlistedAt.Before(*waiting[j].WaitlistedAt)
+	})
+
+	for _, g := range waiting {
+		if !event.HasSeats(accepted) {
+			break
+		}
+		g.InvitationStatus = model.InvitationStatusAccepted
+		g.WaitlistedAt = nil
+		if err := p.gStore.UpdateGuest(ctx, g); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "could not promote guest")
+			return err
+		}
+		span.AddEvent("promoted guest from waitlist")
+		p.logger.InfoContext(ctx, "promoted guest from waitlist", "id", g.ID.String())
+		accepted++
+	}
+	return nil
+}
+
+// UpdateGuestStatus lets admins override the status of a guest, e.g. to
+// accept a guest from the waitlist regardless of the event capacity.
+func (p *GuestHandler) UpdateGuestStatus(c *gin.Context) {
+	var span trace.Span
+	ctx := c.Request.Context()
+	ctx, span = tracer.Start(ctx, "GuestHandler.UpdateGuestStatus")
+	defer span.End()
+
+	guestID, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid guest ID")
+		p.logger.ErrorContext(ctx, "invalid guest ID", "error", err)
+		c.String(http.StatusBadRequest, "invalid guest ID")
+		return
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse form")
+		p.logger.ErrorContext(ctx, "could not parse form", "error", err)
+		c.String(http.StatusBadRequest, "could not parse form")
+		return
+	}
+
+	var update struct {
+		InvitationStatus model.InvitationStatus `form:"invitation_status"`
+	}
+	if err := form.Unmarshal(c.Request.PostForm, &update); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not parse status")
+		p.logger.ErrorContext(ctx, "could not parse status", "error", err)
+		c.String(http.StatusBadRequest, "could not parse status")
+		return
+	}
+
+	event, err := p.eStore.GetEvent(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not find event")
+		p.logger.ErrorContext(ctx, "could not find event", "error", err)
+		c.String(http.StatusInternalServerError, "could not find event")
+		return
+	}
+
+	p.capacityMu.Lock()
+	defer p.capacityMu.Unlock()
+
+	guest, err := p.gStore.GetGuestByID(ctx, guestID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "guest not found")
+		p.logger.WarnContext(ctx, "guest not found", "error", err)
+		c.String(http.StatusNotFound, "guest not found")
+		return
+	}
+
+	guest.InvitationStatus = update.InvitationStatus
+	guest.WaitlistedAt = nil
+	if guest.InvitationStatus == model.InvitationStatusWaitlisted {
+		now := time.Now()
+		guest.WaitlistedAt = &now
+	}
+
+	if err := p.gStore.UpdateGuest(ctx, guest); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "could not update guest")
+		p.logger.ErrorContext(ctx, "could not update guest", "error", err)
+		c.String(http.StatusInternalServerError, "could not update guest")
+		return
+	}
+
+	if err := p.promoteWaitlist(ctx, event); err != nil {
+		p.logger.ErrorContext(ctx, "could not promote waitlist", "error", err)
+	}
+	c.Status(http.StatusNoContent)
+}
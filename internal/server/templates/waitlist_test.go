@@ -0,0 +1,375 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/quixsi/core/internal/model"
+)
+
+// memGuests is a guest store in memory.
+type memGuests map[uuid.UUID]*model.Guest
+
+func (m memGuests) CreateGuest(_ context.Context, g *model.Guest) (uuid.UUID, error) {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	stored := *g
+	m[g.ID] = &stored
+	return g.ID, nil
+}
+
+func (m memGuests) UpdateGuest(_ context.Context, g *model.Guest) error {
+	if _, ok := m[g.ID]; !ok {
+		return errors.New("guest not found")
+	}
+	stored := *g
+	m[g.ID] = &stored
+	return nil
+}
+
+func (m memGuests) DeleteGuest(_ context.Context, id uuid.UUID) error {
+	delete(m, id)
+	return nil
+}
+
+func (m memGuests) ListGuests(context.Context) ([]*model.Guest, error) {
+	var guests []*model.Guest
+	for _, g := range m {
+		copied := *g
+		guests = append(guests, &copied)
+	}
+	return guests, nil
+}
+
+func (m memGuests) GetGuestByID(_ context.Context, id uuid.UUID) (*model.Guest, error) {
+	g, ok := m[id]
+	if !ok {
+		return nil, errors.New("guest not found")
+	}
+	copied := *g
+	return &copied, nil
+}
+
+// memEvent is an event store in memory.
+type memEvent struct{ event model.Event }
+
+func (m *memEvent) GetEvent(context.Context) (*model.Event, error) {
+	copied := m.event
+	return &copied, nil
+}
+
+func (m *memEvent) UpdateEvent(_ context.Context, e *model.Event) error {
+	m.event = *e
+	return nil
+}
+
+// waitlistedAt returns a time minutes after an arbitrary start, or nil if
+// minutes is 0.
+func waitlistedAt(minutes int) *time.Time {
+	if minutes == 0 {
+		return nil
+	}
+	t := time.Date(2024, 5, 1, 12, minutes, 0, 0, time.UTC)
+	return &t
+}
+
+func TestApplyCapacity(t *testing.T) {
+	type change struct {
+		previous, status model.InvitationStatus
+		// waitlisted is the minute the guest joined the waitlist, 0 if not.
+		waitlisted int
+	}
+	tt := []struct {
+		name     string
+		capacity int
+		// others are the statuses of guests that are not changed.
+		others         []model.InvitationStatus
+		changes        []change
+		want           []model.InvitationStatus
+		wantWaitlisted bool
+	}{
+		{
+			name:     "free seat",
+			capacity: 2,
+			others:   []model.InvitationStatus{model.InvitationStatusAccepted},
+			changes:  []change{{previous: model.InvitationStatusNotAnswered, status: model.InvitationStatusAccepted}},
+			want:     []model.InvitationStatus{model.InvitationStatusAccepted},
+		},
+		{
+			name:           "fully booked",
+			capacity:       1,
+			others:         []model.InvitationStatus{model.InvitationStatusAccepted, model.InvitationStatusWaitlisted},
+			changes:        []change{{previous: model.InvitationStatusNotAnswered, status: model.InvitationStatusAccepted}},
+			want:           []model.InvitationStatus{model.InvitationStatusWaitlisted},
+			wantWaitlisted: true,
+		},
+		{
+			name:    "unlimited",
+			others:  []model.InvitationStatus{model.InvitationStatusAccepted, model.InvitationStatusAccepted},
+			changes: []change{{previous: model.InvitationStatusNotAnswered, status: model.InvitationStatusAccepted}},
+			want:    []model.InvitationStatus{model.InvitationStatusAccepted},
+		},
+		{
+			name:     "accepted guests keep their seat",
+			capacity: 1,
+			others:   []model.InvitationStatus{model.InvitationStatusAccepted},
+			changes:  []change{{previous: model.InvitationStatusAccepted, status: model.InvitationStatusAccepted}},
+			want:     []model.InvitationStatus{model.InvitationStatusAccepted},
+		},
+		{
+			name:           "waiting guests keep their place in line",
+			capacity:       5,
+			changes:        []change{{previous: model.InvitationStatusWaitlisted, status: model.InvitationStatusAccepted, waitlisted: 3}},
+			want:           []model.InvitationStatus{model.InvitationStatusWaitlisted},
+			wantWaitlisted: true,
+		},
+		{
+			name:     "last seat",
+			capacity: 2,
+			others:   []model.InvitationStatus{model.InvitationStatusAccepted},
+			changes: []change{
+				{previous: model.InvitationStatusNotAnswered, status: model.InvitationStatusAccepted},
+				{previous: model.InvitationStatusNotAnswered, status: model.InvitationStatusAccepted},
+			},
+			want:           []model.InvitationStatus{model.InvitationStatusAccepted, model.InvitationStatusWaitlisted},
+			wantWaitlisted: true,
+		},
+		{
+			name:     "leave the waitlist",
+			capacity: 1,
+			others:   []model.InvitationStatus{model.InvitationStatusAccepted},
+			changes:  []change{{previous: model.InvitationStatusWaitlisted, status: model.InvitationStatusRejected, waitlisted: 3}},
+			want:     []model.InvitationStatus{model.InvitationStatusRejected},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			guests := make(memGuests)
+			for _, status := range tc.others {
+				if _, err := guests.CreateGuest(ctx, &model.Guest{InvitationStatus: status}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var changes []statusChange
+			for _, ch := range tc.changes {
+				g := &model.Guest{InvitationStatus: ch.previous, WaitlistedAt: waitlistedAt(ch.waitlisted)}
+				if _, err := guests.CreateGuest(ctx, g); err != nil {
+					t.Fatal(err)
+				}
+				g.InvitationStatus = ch.status
+				changes = append(changes, statusChange{guest: g, previous: ch.previous})
+			}
+
+			p := &GuestHandler{gStore: guests, logger: slog.Default()}
+			waitlisted, err := p.applyCapacity(ctx, &model.Event{Capacity: tc.capacity}, changes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if waitlisted != tc.wantWaitlisted {
+				t.Errorf("got waitlisted %v, want %v", waitlisted, tc.wantWaitlisted)
+			}
+			for i, ch := range changes {
+				g := ch.guest
+				if g.InvitationStatus != tc.want[i] {
+					t.Errorf("guest %d: got status %d, want %d", i, g.InvitationStatus, tc.want[i])
+				}
+				switch {
+				case g.InvitationStatus != model.InvitationStatusWaitlisted && g.WaitlistedAt != nil:
+					t.Errorf("guest %d: waitlisted at %v, but not waiting", i, g.WaitlistedAt)
+				case g.InvitationStatus == model.InvitationStatusWaitlisted && g.WaitlistedAt == nil:
+					t.Errorf("guest %d: waiting without waitlist time", i)
+				case tc.changes[i].waitlisted != 0 && g.InvitationStatus == model.InvitationStatusWaitlisted &&
+					!g.WaitlistedAt.Equal(*waitlistedAt(tc.changes[i].waitlisted)):
+					t.Errorf("guest %d: lost its place in line, waitlisted at %v", i, g.WaitlistedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestPromoteWaitlist(t *testing.T) {
+	type guest struct {
+		status model.InvitationStatus
+		// waitlisted is the minute the guest joined the waitlist, 0 if not.
+		waitlisted int
+	}
+	tt := []struct {
+		name     string
+		capacity int
+		guests   []guest
+		want     []model.InvitationStatus
+	}{
+		{
+			name:     "in the order they joined",
+			capacity: 3,
+			guests: []guest{
+				{status: model.InvitationStatusAccepted},
+				{status: model.InvitationStatusWaitlisted, waitlisted: 3},
+				{status: model.InvitationStatusWaitlisted, waitlisted: 1},
+				{status: model.InvitationStatusWaitlisted, waitlisted: 2},
+			},
+			want: []model.InvitationStatus{
+				model.InvitationStatusAccepted,
+				model.InvitationStatusWaitlisted,
+				model.InvitationStatusAccepted,
+				model.InvitationStatusAccepted,
+			},
+		},
+		{
+			name:     "fully booked",
+			capacity: 1,
+			guests: []guest{
+				{status: model.InvitationStatusAccepted},
+				{status: model.InvitationStatusWaitlisted, waitlisted: 1},
+			},
+			want: []model.InvitationStatus{model.InvitationStatusAccepted, model.InvitationStatusWaitlisted},
+		},
+		{
+			name: "unlimited",
+			guests: []guest{
+				{status: model.InvitationStatusWaitlisted, waitlisted: 2},
+				{status: model.InvitationStatusWaitlisted, waitlisted: 1},
+			},
+			want: []model.InvitationStatus{model.InvitationStatusAccepted, model.InvitationStatusAccepted},
+		},
+		{
+			name:     "unknown waitlist time goes last",
+			capacity: 1,
+			guests: []guest{
+				{status: model.InvitationStatusWaitlisted},
+				{status: model.InvitationStatusWaitlisted, waitlisted: 5},
+			},
+			want: []model.InvitationStatus{model.InvitationStatusWaitlisted, model.InvitationStatusAccepted},
+		},
+		{
+			name:     "rejected guests do not wait",
+			capacity: 2,
+			guests: []guest{
+				{status: model.InvitationStatusRejected},
+				{status: model.InvitationStatusNotAnswered},
+			},
+			want: []model.InvitationStatus{model.InvitationStatusRejected, model.InvitationStatusNotAnswered},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			guests := make(memGuests)
+			var ids []uuid.UUID
+			for _, g := range tc.guests {
+				id, err := guests.CreateGuest(ctx, &model.Guest{InvitationStatus: g.status, WaitlistedAt: waitlistedAt(g.waitlisted)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+
+			p := &GuestHandler{gStore: guests, logger: slog.Default()}
+			if err := p.promoteWaitlist(ctx, &model.Event{Capacity: tc.capacity}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, id := range ids {
+				if got := guests[id].InvitationStatus; got != tc.want[i] {
+					t.Errorf("guest %d: got status %d, want %d", i, got, tc.want[i])
+				}
+				if guests[id].InvitationStatus == model.InvitationStatusAccepted && guests[id].WaitlistedAt != nil {
+					t.Errorf("guest %d: promoted, but still waitlisted at %v", i, guests[id].WaitlistedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateGuestStatus(t *testing.T) {
+	tt := []struct {
+		name string
+		// guest is the index of the guest whose status the admin sets.
+		guest  int
+		status model.InvitationStatus
+		want   []model.InvitationStatus
+	}{
+		{
+			name:   "rejection promotes the first waiting guest",
+			guest:  0,
+			status: model.InvitationStatusRejected,
+			want: []model.InvitationStatus{
+				model.InvitationStatusRejected,
+				model.InvitationStatusAccepted,
+				model.InvitationStatusWaitlisted,
+			},
+		},
+		{
+			name:   "admins accept beyond the capacity",
+			guest:  2,
+			status: model.InvitationStatusAccepted,
+			want: []model.InvitationStatus{
+				model.InvitationStatusAccepted,
+				model.InvitationStatusWaitlisted,
+				model.InvitationStatusAccepted,
+			},
+		},
+		{
+			name:   "admins put guests on the waitlist",
+			guest:  0,
+			status: model.InvitationStatusWaitlisted,
+			want: []model.InvitationStatus{
+				model.InvitationStatusWaitlisted,
+				model.InvitationStatusAccepted,
+				model.InvitationStatusWaitlisted,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			guests := make(memGuests)
+			var ids []uuid.UUID
+			for _, g := range []*model.Guest{
+				{InvitationStatus: model.InvitationStatusAccepted},
+				{InvitationStatus: model.InvitationStatusWaitlisted, WaitlistedAt: waitlistedAt(1)},
+				{InvitationStatus: model.InvitationStatusWaitlisted, WaitlistedAt: waitlistedAt(2)},
+			} {
+				id, err := guests.CreateGuest(ctx, g)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+
+			p := &GuestHandler{gStore: guests, eStore: &memEvent{event: model.Event{Capacity: 1}}, logger: slog.Default()}
+			mux := gin.New()
+			mux.POST("/guests/:uuid/status", p.UpdateGuestStatus)
+
+			form := url.Values{"invitation_status": {fmt.Sprint(int(tc.status))}}
+			req := httptest.NewRequest(http.MethodPost, "/guests/"+ids[tc.guest].String()+"/status", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+			}
+
+			for i, id := range ids {
+				if got := guests[id].InvitationStatus; got != tc.want[i] {
+					t.Errorf("guest %d: got status %d, want %d", i, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Package staticmap draws schematic maps of a few places as SVG. They show
+// where the places are relative to each other, with a scale bar and a north
+// arrow, but no streets, so no map provider is needed.
+package staticmap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Width and Height are the size of the view box of a map.
+const (
+	Width  = 640
+	Height = 400
+)
+
+const (
+	// padding keeps markers away from the edges of the map.
+	padding = 40
+	// maxZoom limits the scale of maps whose places are close to each other,
+	// in zoom levels of web maps with tiles of 256 pixels.
+	maxZoom = 16
+	// maxLatitude is the northernmost latitude of the Web Mercator
+	// projection.
+	maxLatitude = 85.05112878
+	// earthCircumference is the circumference of the earth at the equator in
+	// meters.
+	earthCircumference = 40075016.686
+	markerRadius       = 11
+)
+
+// Kind is the kind of a place. It decides the color of its marker.
+type Kind int
+
+const (
+	Venue Kind = iota
+	Hotel
+	Airport
+)
+
+// Color returns the color of the markers of a kind.
+func (k Kind) Color() string {
+	switch k {
+	case Venue:
+		return "#dc2626"
+	case Hotel:
+		return "#2563eb"
+	default:
+		return "#059669"
+	}
+}
+
+// Marker is a place on a map.
+type Marker struct {
+	Kind Kind
+	// Label is written into the marker, e.g. "1".
+	Label string
+	// Title is shown when hovering the marker.
+	Title     string
+	Latitude  float64
+	Longitude float64
+}
+
+// Valid reports whether a place with the given coordinates can be shown. The
+// coordinates 0,0 are taken as not set.
+func Valid(latitude, longitude float64) bool {
+	if latitude == 0 && longitude == 0 {
+		return false
+	}
+	return math.Abs(latitude) <= maxLatitude && math.Abs(longitude) <= 180
+}
+
+// project returns the Web Mercator coordinates of a place, both from 0 to 1
+// with y growing to the south.
+func project(latitude, longitude float64) (x, y float64) {
+	phi := latitude * math.Pi / 180
+	x = (longitude + 180) / 360
+	y = (1 - math.Log(math.Tan(phi)+1/math.Cos(phi))/math.Pi) / 2
+	return x, y
+}
+
+// view maps projected coordinates to the pixels of a map.
+type view struct {
+	// centerX and centerY are the projected center of the map.
+	centerX, centerY float64
+	// scale is the number of pixels per projected unit.
+	scale float64
+}
+
+// fit returns the view showing all points, as close as maxZoom allows.
+//
+// NOTE: places on both sides of the antimeridian are shown around the world.
+func fit(points [][2]float64) view {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		minX, maxX = min(minX, p[0]), max(maxX, p[0])
+		minY, maxY = min(minY, p[1]), max(maxY, p[1])
+	}
+	scale := float64(256 << maxZoom)
+	if dx := maxX - minX; dx > 0 {
+		scale = min(scale, (Width-2*padding)/dx)
+	}
+	if dy := maxY - minY; dy > 0 {
+		scale = min(scale, (Height-2*padding)/dy)
+	}
+	return view{centerX: (minX + maxX) / 2, centerY: (minY + maxY) / 2, scale: scale}
+}
+
+func (v view) pixel(x, y float64) (float64, float64) {
+	return (x-v.centerX)*v.scale + Width/2, (y-v.centerY)*v.scale + Height/2
+}
+
+// metersPerPixel returns the distance in meters a pixel covers at the center
+// of the view.
+func (v view) metersPerPixel() float64 {
+	latitude := math.Atan(math.Sinh(math.Pi*(1-2*v.centerY))) * 180 / math.Pi
+	return earthCircumference * math.Cos(latitude*math.Pi/180) / v.scale
+}
+
+// niceDistance rounds a distance in meters down to 1, 2 or 5 times a power of
+// ten.
+func niceDistance(meters float64) float64 {
+	if meters <= 0 {
+		return 0
+	}
+	pow := math.Pow(10, math.Floor(math.Log10(meters)))
+	for _, f := range []float64{5, 2} {
+		if f*pow <= meters {
+			return f * pow
+		}
+	}
+	return pow
+}
+
+// formatDistance formats a distance of niceDistance, e.g. "500 m" or "2 km".
+func formatDistance(meters float64) string {
+	if meters >= 1000 {
+		return strconv.FormatFloat(meters/1000, 'f', -1, 64) + " km"
+	}
+	return strconv.FormatFloat(meters, 'f', -1, 64) + " m"
+}
+
+// Render draws the markers with valid coordinates on a map. The title
+// describes the map for screen readers. Markers are drawn in order, so later
+// ones cover earlier ones. Render returns nil if no marker can be shown.
+func Render(title string, markers []Marker) []byte {
+	var shown []Marker
+	var points [][2]float64
+	for _, m := range markers {
+		if Valid(m.Latitude, m.Longitude) {
+			x, y := project(m.Latitude, m.Longitude)
+			shown = append(shown, m)
+			points = append(points, [2]float64{x, y})
+		}
+	}
+	if len(shown) == 0 {
+		return nil
+	}
+	v := fit(points)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" role="img" aria-label="%s">`, Width, Height, escape(title))
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#f3f4f6"/>`, Width, Height)
+	for x := 80; x < Width; x += 80 {
+		fmt.Fprintf(&b, `<line x1="%d" y1="0" x2="%d" y2="%d" stroke="#e5e7eb"/>`, x, x, Height)
+	}
+	for y := 80; y < Height; y += 80 {
+		fmt.Fprintf(&b, `<line x1="0" y1="%d" x2="%d" y2="%d" stroke="#e5e7eb"/>`, y, Width, y)
+	}
+
+	for i, m := range shown {
+		x, y := v.pixel(points[i][0], points[i][1])
+		fmt.Fprintf(&b, `<g><title>%s</title>`, escape(m.Title))
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="%d" fill="%s" stroke="#ffffff" stroke-width="2"/>`, x, y, markerRadius, m.Kind.Color())
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="central" font-family="sans-serif" font-size="12" font-weight="bold" fill="#ffffff">%s</text></g>`, x, y, escape(m.Label))
+	}
+
+	// NOTE: the scale bar is about a fifth of the map wide and starts at the
+	// bottom left.
+	meters := niceDistance(v.metersPerPixel() * Width / 5)
+	length := meters / v.metersPerPixel()
+	fmt.Fprintf(&b, `<path d="M16 %d v6 h%.1f v-6" fill="none" stroke="#374151" stroke-width="2"/>`, Height-22, length)
+	fmt.Fprintf(&b, `<text x="16" y="%d" font-family="sans-serif" font-size="12" fill="#374151">%s</text>`, Height-26, formatDistance(meters))
+
+	fmt.Fprintf(&b, `<path d="M%d 14 l8 22 l-8 -6 l-8 6 z" fill="#374151"/>`, Width-24)
+	fmt.Fprintf(&b, `<text x="%d" y="50" text-anchor="middle" font-family="sans-serif" font-size="12" font-weight="bold" fill="#374151">N</text>`, Width-24)
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+package staticmap
+
+import (
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	tt := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		want      bool
+	}{
+		{name: "berlin", latitude: 52.52, longitude: 13.405, want: true},
+		{name: "equator", latitude: 0, longitude: 13.405, want: true},
+		{name: "not set", latitude: 0, longitude: 0, want: false},
+		{name: "north pole", latitude: 90, longitude: 0, want: false},
+		{name: "longitude out of range", latitude: 10, longitude: 181, want: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Valid(tc.latitude, tc.longitude); got != tc.want {
+				t.Errorf("Valid(%v, %v) = %v, want %v", tc.latitude, tc.longitude, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	tt := []struct {
+		name   string
+		places [][2]float64
+	}{
+		{name: "single place", places: [][2]float64{{52.52, 13.405}}},
+		{name: "city", places: [][2]float64{{52.52, 13.405}, {52.50, 13.45}, {52.36, 13.50}}},
+		{name: "north to south", places: [][2]float64{{53.55, 9.99}, {48.14, 11.58}}},
+		{name: "continents", places: [][2]float64{{52.52, 13.405}, {40.71, -74.0}, {-33.87, 151.21}}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var points [][2]float64
+			for _, p := range tc.places {
+				x, y := project(p[0], p[1])
+				points = append(points, [2]float64{x, y})
+			}
+			v := fit(points)
+			if v.scale > 256<<maxZoom {
+				t.Errorf("scale %v exceeds zoom level %d", v.scale, maxZoom)
+			}
+			for _, p := range points {
+				x, y := v.pixel(p[0], p[1])
+				if x < padding-1e-6 || x > Width-padding+1e-6 || y < padding-1e-6 || y > Height-padding+1e-6 {
+					t.Errorf("point at %.1f,%.1f is outside of the padded map", x, y)
+				}
+			}
+			if len(points) == 1 {
+				x, y := v.pixel(points[0][0], points[0][1])
+				if x != Width/2 || y != Height/2 {
+					t.Errorf("single point at %.1f,%.1f, want the center", x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestView_metersPerPixel(t *testing.T) {
+	x, y := project(60, 10)
+	v := view{centerX: x, centerY: y, scale: 256}
+	// NOTE: at 60° a pixel covers half the distance it covers at the equator.
+	want := earthCircumference / 256 / 2
+	if got := v.metersPerPixel(); math.Abs(got-want) > 1 {
+		t.Errorf("metersPerPixel() = %v, want %v", got, want)
+	}
+}
+
+func TestNiceDistance(t *testing.T) {
+	tt := []struct {
+		meters float64
+		want   float64
+		text   string
+	}{
+		{meters: 0.7, want: 0.5, text: "0.5 m"},
+		{meters: 180, want: 100, text: "100 m"},
+		{meters: 260, want: 200, text: "200 m"},
+		{meters: 999, want: 500, text: "500 m"},
+		{meters: 1000, want: 1000, text: "1 km"},
+		{meters: 7300, want: 5000, text: "5 km"},
+		{meters: 2_400_000, want: 2_000_000, text: "2000 km"},
+	}
+	for _, tc := range tt {
+		got := niceDistance(tc.meters)
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("niceDistance(%v) = %v, want %v", tc.meters, got, tc.want)
+		}
+		if text := formatDistance(got); text != tc.text {
+			t.Errorf("formatDistance(%v) = %q, want %q", got, text, tc.text)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tt := []struct {
+		name        string
+		markers     []Marker
+		wantMarkers int
+	}{
+		{name: "no markers"},
+		{name: "no coordinates", markers: []Marker{{Label: "1", Title: "Venue"}}},
+		{
+			name: "venue and hotel",
+			markers: []Marker{
+				{Kind: Venue, Label: "1", Title: "Castle <Venue> & Garden", Latitude: 52.52, Longitude: 13.405},
+				{Kind: Hotel, Label: "2", Title: "Hotel", Latitude: 52.50, Longitude: 13.45},
+				{Kind: Airport, Label: "3", Title: "Unknown"},
+			},
+			wantMarkers: 2,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Render(`Map of "the" venue`, tc.markers)
+			if tc.wantMarkers == 0 {
+				if got != nil {
+					t.Errorf("Render() = %s, want nil", got)
+				}
+				return
+			}
+
+			var svg struct {
+				XMLName xml.Name `xml:"svg"`
+				Label   string   `xml:"aria-label,attr"`
+				Groups  []struct {
+					Title string `xml:"title"`
+					Text  string `xml:"text"`
+				} `xml:"g"`
+			}
+			if err := xml.Unmarshal(got, &svg); err != nil {
+				t.Fatalf("invalid SVG: %v\n%s", err, got)
+			}
+			if svg.Label != `Map of "the" venue` {
+				t.Errorf("aria-label = %q", svg.Label)
+			}
+			if len(svg.Groups) != tc.wantMarkers {
+				t.Fatalf("got %d markers, want %d", len(svg.Groups), tc.wantMarkers)
+			}
+			if svg.Groups[0].Title != "Castle <Venue> & Garden" || svg.Groups[0].Text != "1" {
+				t.Errorf("first marker = %+v", svg.Groups[0])
+			}
+			if !strings.Contains(string(got), Hotel.Color()) {
+				t.Errorf("hotel marker is not colored %s", Hotel.Color())
+			}
+		})
+	}
+}
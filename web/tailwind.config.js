@@ -0,0 +1,31 @@
+// Copyright (C) 2024 the quixsi maintainers
+// See root-dir/LICENSE for more information
+
+// Compiled by `make css` to internal/server/static/css/tailwind.css.
+/** @type {import('tailwindcss').Config} */
+module.exports = {
+  content: {
+    relative: true,
+    files: [
+      "../internal/server/templates/*.html",
+      "../internal/server/templates/*.go",
+      "../internal/server/static/js/*.js",
+    ],
+  },
+  theme: {
+    extend: {
+      colors: {
+        danger: {
+          100: "#fbe5ea",
+          200: "#f5c2cc",
+          700: "#b0263e",
+        },
+        success: {
+          DEFAULT: "#14a44d",
+          100: "#d6faec",
+          700: "#0e7245",
+        },
+      },
+    },
+  },
+};